@@ -0,0 +1,95 @@
+package metrics
+
+import "sync/atomic"
+
+// Counter holds an int64 value that can be incremented and decremented. Its
+// current value is read by calling Snapshot, which returns an immutable
+// CounterSnapshot rather than exposing Count directly: that keeps the writer
+// side a pure atomic increment/decrement and makes it explicit at call sites
+// that a read is a point-in-time copy, not a live view. Gauge and
+// GaugeFloat64 split the same way, for the same reason.
+type Counter interface {
+	Clear()
+	Dec(int64)
+	Inc(int64)
+	Snapshot() CounterSnapshot
+}
+
+// CounterSnapshot is a read-only copy of a Counter's value at the moment
+// Snapshot was called.
+type CounterSnapshot interface {
+	Count() int64
+}
+
+// GetOrRegisterCounter returns an existing Counter or constructs and
+// registers a new StandardCounter.
+func GetOrRegisterCounter(name string, r Registry) Counter {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewCounter).(Counter)
+}
+
+// NewCounter constructs a new StandardCounter.
+func NewCounter() Counter {
+	if UseNilMetrics {
+		return NilCounter{}
+	}
+	return &StandardCounter{}
+}
+
+// NewRegisteredCounter constructs and registers a new StandardCounter.
+func NewRegisteredCounter(name string, r Registry) Counter {
+	c := NewCounter()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// NilCounter is a no-op Counter.
+type NilCounter struct{}
+
+// Clear is a no-op.
+func (NilCounter) Clear() {}
+
+// Dec is a no-op.
+func (NilCounter) Dec(i int64) {}
+
+// Inc is a no-op.
+func (NilCounter) Inc(i int64) {}
+
+// Snapshot returns a snapshot whose Count is always zero.
+func (NilCounter) Snapshot() CounterSnapshot { return counterSnapshot(0) }
+
+// StandardCounter is the standard implementation of a Counter.
+type StandardCounter struct {
+	count int64
+}
+
+// Clear sets the counter to zero.
+func (c *StandardCounter) Clear() {
+	atomic.StoreInt64(&c.count, 0)
+}
+
+// Dec decrements the counter by the given amount.
+func (c *StandardCounter) Dec(i int64) {
+	atomic.AddInt64(&c.count, -i)
+}
+
+// Inc increments the counter by the given amount.
+func (c *StandardCounter) Inc(i int64) {
+	atomic.AddInt64(&c.count, i)
+}
+
+// Snapshot returns a read-only copy of the counter's current value.
+func (c *StandardCounter) Snapshot() CounterSnapshot {
+	return counterSnapshot(atomic.LoadInt64(&c.count))
+}
+
+// counterSnapshot is a read-only copy of a Counter's value.
+type counterSnapshot int64
+
+// Count returns the snapshotted value.
+func (c counterSnapshot) Count() int64 { return int64(c) }
@@ -0,0 +1,74 @@
+package metrics
+
+import "testing"
+
+func TestCounterClear(t *testing.T) {
+	c := NewCounter()
+	c.Inc(1)
+	c.Clear()
+	if count := c.Snapshot().Count(); count != 0 {
+		t.Errorf("Snapshot().Count() = %d, want 0", count)
+	}
+}
+
+func TestCounterInc1(t *testing.T) {
+	c := NewCounter()
+	c.Inc(1)
+	if count := c.Snapshot().Count(); count != 1 {
+		t.Errorf("Snapshot().Count() = %d, want 1", count)
+	}
+}
+
+func TestCounterInc(t *testing.T) {
+	c := NewCounter()
+	c.Inc(2)
+	c.Inc(3)
+	if count := c.Snapshot().Count(); count != 5 {
+		t.Errorf("Snapshot().Count() = %d, want 5", count)
+	}
+}
+
+func TestCounterDec1(t *testing.T) {
+	c := NewCounter()
+	c.Dec(1)
+	if count := c.Snapshot().Count(); count != -1 {
+		t.Errorf("Snapshot().Count() = %d, want -1", count)
+	}
+}
+
+func TestCounterDec(t *testing.T) {
+	c := NewCounter()
+	c.Dec(2)
+	c.Dec(3)
+	if count := c.Snapshot().Count(); count != -5 {
+		t.Errorf("Snapshot().Count() = %d, want -5", count)
+	}
+}
+
+func TestCounterSnapshot(t *testing.T) {
+	c := NewCounter()
+	c.Inc(1)
+	snapshot := c.Snapshot()
+	c.Inc(1)
+	if count := snapshot.Count(); count != 1 {
+		t.Errorf("Snapshot().Count() = %d, want 1 (unaffected by later Inc)", count)
+	}
+}
+
+func TestGetOrRegisterCounter(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("foo", r).Inc(47)
+	if count := GetOrRegisterCounter("foo", r).Snapshot().Count(); count != 47 {
+		t.Errorf("GetOrRegisterCounter().Snapshot().Count() = %d, want 47", count)
+	}
+}
+
+func TestNilCounter(t *testing.T) {
+	c := NilCounter{}
+	c.Inc(1)
+	c.Dec(1)
+	c.Clear()
+	if count := c.Snapshot().Count(); count != 0 {
+		t.Errorf("Snapshot().Count() = %d, want 0", count)
+	}
+}
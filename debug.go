@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+var (
+	debugMetrics struct {
+		GCStats struct {
+			LastGC  Gauge
+			NumGC   Gauge
+			PauseNs Histogram
+		}
+		ReadGCStats Timer
+	}
+	gcStats   debug.GCStats
+	lastNumGC int64
+)
+
+// CaptureDebugGCStats is a blocking helper that periodically calls
+// CaptureDebugGCStatsOnce; run it in its own goroutine.
+func CaptureDebugGCStats(r Registry, d time.Duration) {
+	for range time.Tick(d) {
+		CaptureDebugGCStatsOnce(r)
+	}
+}
+
+// CaptureDebugGCStatsOnce samples runtime/debug.GCStats, updating every
+// metric registered by RegisterDebugGCStats. It must be called after
+// RegisterDebugGCStats, and must be called from a single goroutine at a
+// time since debug.ReadGCStats reuses gcStats.Pause across calls.
+func CaptureDebugGCStatsOnce(r Registry) {
+	t := time.Now()
+	debug.ReadGCStats(&gcStats)
+	debugMetrics.ReadGCStats.UpdateSince(t)
+
+	debugMetrics.GCStats.LastGC.Update(int64(gcStats.LastGC.UnixNano()))
+	debugMetrics.GCStats.NumGC.Update(gcStats.NumGC)
+
+	// gcStats.Pause is ordered most-recent-first and re-reports pauses from
+	// earlier captures until they age out of the runtime's ring buffer, so
+	// only the entries for GCs that happened since the last capture (i.e.
+	// the front of the slice) are new.
+	newGCs := gcStats.NumGC - lastNumGC
+	if newGCs > int64(len(gcStats.Pause)) {
+		newGCs = int64(len(gcStats.Pause))
+	}
+	for _, pause := range gcStats.Pause[:newGCs] {
+		debugMetrics.GCStats.PauseNs.Update(int64(pause))
+	}
+	lastNumGC = gcStats.NumGC
+}
+
+// RegisterDebugGCStats registers the metrics CaptureDebugGCStatsOnce fills
+// in: the time of the last GC, the cumulative number of GCs, and a
+// histogram of pause durations. Giving each a fixed identity up front is
+// what lets later captures update them in place instead of re-registering
+// on every tick.
+func RegisterDebugGCStats(r Registry) {
+	debugMetrics.GCStats.LastGC = NewGauge()
+	debugMetrics.GCStats.NumGC = NewGauge()
+	debugMetrics.GCStats.PauseNs = NewHistogram(NewExpDecaySample(WithReservoirSize(1028), WithAlpha(0.015)))
+	debugMetrics.ReadGCStats = NewTimer()
+
+	r.Register("debug.GCStats.LastGC", debugMetrics.GCStats.LastGC)
+	r.Register("debug.GCStats.NumGC", debugMetrics.GCStats.NumGC)
+	r.Register("debug.GCStats.PauseNs", debugMetrics.GCStats.PauseNs)
+	r.Register("debug.GCStats.ReadGCStats", debugMetrics.ReadGCStats)
+}
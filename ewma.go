@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+const ewmaInterval = 5 // seconds between Tick calls, matching the Unix load average convention
+
+// EWMA is an exponentially-weighted moving average, used by Meter to
+// compute its 1/5/15-minute rates the same way the Unix load average does.
+type EWMA interface {
+	Rate() float64
+	Tick()
+	Update(int64)
+}
+
+// NewEWMA constructs a new EWMA with the given smoothing factor, which
+// should be one of the values returned by alphaFor(minutes).
+func NewEWMA(alpha float64) EWMA {
+	return &StandardEWMA{alpha: alpha}
+}
+
+// NewEWMA1 constructs a EWMA matching the Unix 1-minute load average.
+func NewEWMA1() EWMA { return NewEWMA(alphaFor(1)) }
+
+// NewEWMA5 constructs a EWMA matching the Unix 5-minute load average.
+func NewEWMA5() EWMA { return NewEWMA(alphaFor(5)) }
+
+// NewEWMA15 constructs a EWMA matching the Unix 15-minute load average.
+func NewEWMA15() EWMA { return NewEWMA(alphaFor(15)) }
+
+func alphaFor(minutes float64) float64 {
+	return 1 - math.Exp(-float64(ewmaInterval)/60/minutes)
+}
+
+// StandardEWMA is the standard implementation of an EWMA.
+type StandardEWMA struct {
+	mutex       sync.Mutex
+	alpha       float64
+	rate        float64
+	initialized bool
+	uncounted   int64 // accessed atomically between Tick calls
+}
+
+// Rate returns the moving average rate of events per second.
+func (a *StandardEWMA) Rate() float64 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.rate
+}
+
+// Tick folds the events accumulated via Update since the last Tick into the
+// moving average; it must be called once per ewmaInterval.
+func (a *StandardEWMA) Tick() {
+	count := atomic.SwapInt64(&a.uncounted, 0)
+	instantRate := float64(count) / float64(ewmaInterval)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.initialized {
+		a.rate += a.alpha * (instantRate - a.rate)
+	} else {
+		a.rate = instantRate
+		a.initialized = true
+	}
+}
+
+// Update records n events since the last Tick.
+func (a *StandardEWMA) Update(n int64) {
+	atomic.AddInt64(&a.uncounted, n)
+}
@@ -0,0 +1,186 @@
+// Package exp publishes a metrics.Registry over HTTP: as JSON under
+// /debug/metrics (and mirrored into the standard library's expvar under
+// /debug/vars), or as Prometheus text exposition format for clients that ask
+// for it via the Accept header.
+package exp
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"sort"
+	"strconv"
+
+	promtext "github.com/weareyolo/go-metrics/prometheus"
+
+	metrics "github.com/weareyolo/go-metrics"
+)
+
+// DefaultPercentiles are the percentiles reported for Histogram/Timer
+// metrics. Handler reads it when called and the expvar mirror reads it on
+// every render; Handler itself takes no percentile argument.
+var DefaultPercentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// Setup registers Handler(metrics.DefaultRegistry) under /debug/metrics on
+// http.DefaultServeMux and starts listening on addr. It is a convenience for
+// the common case of mounting metrics exposition alongside net/http/pprof,
+// which also registers itself on http.DefaultServeMux.
+func Setup(addr string) error {
+	http.Handle("/debug/metrics", Handler(metrics.DefaultRegistry))
+	return http.ListenAndServe(addr, nil)
+}
+
+// Handler returns an http.Handler that serves every metric in r as JSON, and
+// as a side effect of being constructed, publishes each metric into expvar
+// so it also shows up under /debug/vars. When a request's Accept header
+// mentions text/plain or openmetrics-text anywhere (q-values are not
+// weighed), the handler streams the Prometheus text exposition format
+// instead of JSON, so callers don't have to choose between the two
+// endpoints.
+func Handler(r metrics.Registry) http.Handler {
+	publishExpvar(r)
+	return &handler{registry: r, percentiles: DefaultPercentiles}
+}
+
+type handler struct {
+	registry    metrics.Registry
+	percentiles []float64
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if wantsPrometheus(req) {
+		var buf bytes.Buffer
+		promtext.WriteText(&buf, h.registry, "", h.percentiles)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(buf.Bytes())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(snapshotAll(h.registry, h.percentiles))
+}
+
+// wantsPrometheus reports whether req's Accept header asks for the
+// Prometheus text exposition format rather than the default JSON.
+func wantsPrometheus(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	return bytes.Contains([]byte(accept), []byte("text/plain")) ||
+		bytes.Contains([]byte(accept), []byte("openmetrics-text"))
+}
+
+// snapshotAll renders every metric in r into a plain map suitable for JSON
+// encoding, translating Histogram/Timer into nested objects with count,
+// min, max, mean, stddev and the configured percentiles.
+func snapshotAll(r metrics.Registry, percentiles []float64) map[string]interface{} {
+	out := make(map[string]interface{})
+	r.Each(func(name string, i interface{}) {
+		switch metric := i.(type) {
+		case metrics.Counter:
+			out[name] = metric.Snapshot().Count()
+		case metrics.Gauge:
+			out[name] = metric.Snapshot().Value()
+		case metrics.GaugeFloat64:
+			out[name] = metric.Snapshot().Value()
+		case metrics.Histogram:
+			snapshot := metric.Snapshot()
+			out[name] = histogramJSON(snapshot.Count(), snapshot.Min(), snapshot.Max(),
+				snapshot.Mean(), snapshot.StdDev(), snapshot.Percentiles(percentiles), percentiles)
+		case metrics.Meter:
+			snapshot := metric.Snapshot()
+			out[name] = map[string]interface{}{
+				"count":  snapshot.Count(),
+				"rate1":  snapshot.Rate1(),
+				"rate5":  snapshot.Rate5(),
+				"rate15": snapshot.Rate15(),
+			}
+		case metrics.Timer:
+			snapshot := metric.Snapshot()
+			m := histogramJSON(snapshot.Count(), snapshot.Min(), snapshot.Max(),
+				snapshot.Mean(), snapshot.StdDev(), snapshot.Percentiles(percentiles), percentiles)
+			m["rate1"] = snapshot.Rate1()
+			out[name] = m
+		case metrics.ResettingTimer:
+			snapshot := metric.Snapshot()
+			ps := snapshot.Percentiles(percentiles)
+			out[name] = histogramJSON(int64(snapshot.Count()), snapshot.Min(), snapshot.Max(),
+				snapshot.Mean(), 0, toFloat64(ps), percentiles)
+		}
+	})
+	return out
+}
+
+func histogramJSON(count, min, max int64, mean, stddev float64, ps []float64, percentiles []float64) map[string]interface{} {
+	m := map[string]interface{}{
+		"count":  count,
+		"min":    min,
+		"max":    max,
+		"mean":   mean,
+		"stddev": stddev,
+	}
+	for i, p := range percentiles {
+		m[percentileKey(p)] = ps[i]
+	}
+	return m
+}
+
+// percentileKey renders a fraction like 0.999 as the JSON key "p99.9".
+func percentileKey(p float64) string {
+	return "p" + strconv.FormatFloat(p*100, 'f', -1, 64)
+}
+
+func toFloat64(in []int64) []float64 {
+	out := make([]float64, len(in))
+	for i, v := range in {
+		out[i] = float64(v)
+	}
+	return out
+}
+
+// publishExpvar mirrors every metric currently in r into the standard
+// library's expvar.Publish map, under the same name, so that /debug/vars
+// shows them alongside anything else the process has published. Registry
+// contents are fixed at the time Handler is constructed; metrics registered
+// afterwards are not retroactively published to expvar (expvar.Publish
+// panics on a duplicate name, so this can only be done once per metric).
+func publishExpvar(r metrics.Registry) {
+	names := make([]string, 0)
+	r.Each(func(name string, _ interface{}) {
+		names = append(names, name)
+	})
+	sort.Strings(names)
+
+	for _, name := range names {
+		name := name
+		if expvar.Get(name) != nil {
+			continue
+		}
+		expvar.Publish(name, expvar.Func(func() interface{} {
+			i := r.Get(name)
+			if i == nil {
+				return nil
+			}
+			out := snapshotAll(singleMetricRegistry{name: name, metric: i}, DefaultPercentiles)
+			return out[name]
+		}))
+	}
+}
+
+// singleMetricRegistry adapts a single (name, metric) pair to the
+// metrics.Registry interface so snapshotAll can be reused to render it.
+type singleMetricRegistry struct {
+	name   string
+	metric interface{}
+}
+
+func (s singleMetricRegistry) Each(f func(string, interface{})) { f(s.name, s.metric) }
+func (s singleMetricRegistry) Get(name string) interface{} {
+	if name == s.name {
+		return s.metric
+	}
+	return nil
+}
+func (s singleMetricRegistry) GetOrRegister(string, interface{}) interface{} { return s.metric }
+func (s singleMetricRegistry) Register(string, interface{}) error            { return nil }
+func (s singleMetricRegistry) RunHealthchecks()                              {}
+func (s singleMetricRegistry) Unregister(string)                             {}
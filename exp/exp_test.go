@@ -0,0 +1,89 @@
+package exp
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metrics "github.com/weareyolo/go-metrics"
+)
+
+func TestHandlerServesJSONByDefault(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("my.counter", r).Inc(3)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/metrics", nil)
+	w := httptest.NewRecorder()
+	Handler(r).ServeHTTP(w, req)
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json prefix", ct)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, body = %s", err, w.Body.String())
+	}
+	if out["my.counter"] != float64(3) {
+		t.Errorf(`out["my.counter"] = %v, want 3`, out["my.counter"])
+	}
+}
+
+func TestHandlerServesPrometheusTextOnAccept(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("my.counter", r).Inc(3)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/metrics", nil)
+	req.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+	Handler(r).ServeHTTP(w, req)
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain prefix", ct)
+	}
+	if !strings.Contains(w.Body.String(), "my_counter 3\n") {
+		t.Errorf("body = %q, want a my_counter 3 line", w.Body.String())
+	}
+}
+
+// TestSnapshotAllDistinguishesP99AndP999 guards against the label collision
+// this series fixed elsewhere: 0.99 and 0.999 must render as distinct JSON
+// keys ("p99" and "p99.9"), not collide under the same key.
+func TestSnapshotAllDistinguishesP99AndP999(t *testing.T) {
+	r := metrics.NewRegistry()
+	h := metrics.GetOrRegisterHistogram("my.histogram", r, metrics.NewUniformSample(100))
+	for i := int64(1); i <= 1000; i++ {
+		h.Update(i)
+	}
+
+	out := snapshotAll(r, []float64{0.99, 0.999})
+	fields, ok := out["my.histogram"].(map[string]interface{})
+	if !ok {
+		t.Fatalf(`out["my.histogram"] = %T, want map[string]interface{}`, out["my.histogram"])
+	}
+	if _, ok := fields["p99"]; !ok {
+		t.Errorf("fields = %v, missing p99", fields)
+	}
+	if _, ok := fields["p99.9"]; !ok {
+		t.Errorf("fields = %v, missing p99.9", fields)
+	}
+}
+
+func TestPublishExpvarMirrorsRegistry(t *testing.T) {
+	r := metrics.NewRegistry()
+	name := "exp.test.unique.counter"
+	metrics.GetOrRegisterCounter(name, r).Inc(9)
+
+	Handler(r)
+
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatalf("expvar.Get(%q) = nil, want a published Func", name)
+	}
+	if got := v.String(); got != "9" {
+		t.Errorf("expvar %q = %q, want \"9\"", name, got)
+	}
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import "sync/atomic"
+
+// Gauge holds an int64 value that can be set directly. Its current value is
+// read by calling Snapshot, which returns an immutable GaugeSnapshot rather
+// than exposing Value directly; see Counter for why.
+type Gauge interface {
+	Snapshot() GaugeSnapshot
+	Update(int64)
+	// UpdateIfGt sets the gauge to v if v is greater than its current
+	// value, e.g. for tracking a high-water mark such as the largest
+	// deletion batch size seen this run.
+	UpdateIfGt(int64)
+	// UpdateIfLt sets the gauge to v if v is less than its current value.
+	UpdateIfLt(int64)
+}
+
+// GaugeSnapshot is a read-only copy of a Gauge's value at the moment
+// Snapshot was called.
+type GaugeSnapshot interface {
+	Value() int64
+}
+
+// GetOrRegisterGauge returns an existing Gauge or constructs and registers a
+// new StandardGauge.
+func GetOrRegisterGauge(name string, r Registry) Gauge {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewGauge).(Gauge)
+}
+
+// NewGauge constructs a new StandardGauge.
+func NewGauge() Gauge {
+	if UseNilMetrics {
+		return NilGauge{}
+	}
+	return &StandardGauge{}
+}
+
+// NewRegisteredGauge constructs and registers a new StandardGauge.
+func NewRegisteredGauge(name string, r Registry) Gauge {
+	c := NewGauge()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// NilGauge is a no-op Gauge.
+type NilGauge struct{}
+
+// Snapshot returns a snapshot whose Value is always zero.
+func (NilGauge) Snapshot() GaugeSnapshot { return gaugeSnapshot(0) }
+
+// Update is a no-op.
+func (NilGauge) Update(v int64) {}
+
+// UpdateIfGt is a no-op.
+func (NilGauge) UpdateIfGt(v int64) {}
+
+// UpdateIfLt is a no-op.
+func (NilGauge) UpdateIfLt(v int64) {}
+
+// StandardGauge is the standard implementation of a Gauge.
+type StandardGauge struct {
+	value int64
+}
+
+// Snapshot returns a read-only copy of the gauge's current value.
+func (g *StandardGauge) Snapshot() GaugeSnapshot {
+	return gaugeSnapshot(atomic.LoadInt64(&g.value))
+}
+
+// Update sets the gauge's value.
+func (g *StandardGauge) Update(v int64) {
+	atomic.StoreInt64(&g.value, v)
+}
+
+// UpdateIfGt sets the gauge's value to v if v is greater than the current
+// value, retrying the compare-and-swap until it wins the race rather than
+// taking a lock.
+func (g *StandardGauge) UpdateIfGt(v int64) {
+	for {
+		current := atomic.LoadInt64(&g.value)
+		if v <= current {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&g.value, current, v) {
+			return
+		}
+	}
+}
+
+// UpdateIfLt sets the gauge's value to v if v is less than the current
+// value, retrying the compare-and-swap until it wins the race.
+func (g *StandardGauge) UpdateIfLt(v int64) {
+	for {
+		current := atomic.LoadInt64(&g.value)
+		if v >= current {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&g.value, current, v) {
+			return
+		}
+	}
+}
+
+// gaugeSnapshot is a read-only copy of a Gauge's value.
+type gaugeSnapshot int64
+
+// Value returns the snapshotted value.
+func (g gaugeSnapshot) Value() int64 { return int64(g) }
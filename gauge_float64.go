@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// GaugeFloat64 holds a float64 value that can be set directly. Its current
+// value is read by calling Snapshot, which returns an immutable
+// GaugeFloat64Snapshot rather than exposing Value directly; see Counter for
+// why.
+type GaugeFloat64 interface {
+	Snapshot() GaugeFloat64Snapshot
+	Update(float64)
+	// UpdateIfGt sets the gauge to v if v is greater than its current
+	// value.
+	UpdateIfGt(float64)
+	// UpdateIfLt sets the gauge to v if v is less than its current value.
+	UpdateIfLt(float64)
+}
+
+// GaugeFloat64Snapshot is a read-only copy of a GaugeFloat64's value at the
+// moment Snapshot was called.
+type GaugeFloat64Snapshot interface {
+	Value() float64
+}
+
+// GetOrRegisterGaugeFloat64 returns an existing GaugeFloat64 or constructs
+// and registers a new StandardGaugeFloat64.
+func GetOrRegisterGaugeFloat64(name string, r Registry) GaugeFloat64 {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewGaugeFloat64).(GaugeFloat64)
+}
+
+// NewGaugeFloat64 constructs a new StandardGaugeFloat64.
+func NewGaugeFloat64() GaugeFloat64 {
+	if UseNilMetrics {
+		return NilGaugeFloat64{}
+	}
+	return &StandardGaugeFloat64{}
+}
+
+// NewRegisteredGaugeFloat64 constructs and registers a new
+// StandardGaugeFloat64.
+func NewRegisteredGaugeFloat64(name string, r Registry) GaugeFloat64 {
+	c := NewGaugeFloat64()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// NilGaugeFloat64 is a no-op GaugeFloat64.
+type NilGaugeFloat64 struct{}
+
+// Snapshot returns a snapshot whose Value is always zero.
+func (NilGaugeFloat64) Snapshot() GaugeFloat64Snapshot { return gaugeFloat64Snapshot(0) }
+
+// Update is a no-op.
+func (NilGaugeFloat64) Update(v float64) {}
+
+// UpdateIfGt is a no-op.
+func (NilGaugeFloat64) UpdateIfGt(v float64) {}
+
+// UpdateIfLt is a no-op.
+func (NilGaugeFloat64) UpdateIfLt(v float64) {}
+
+// StandardGaugeFloat64 is the standard implementation of a GaugeFloat64. The
+// value is stored as the bit pattern of a float64 in a uint64 so it can be
+// read, written, and compare-and-swapped atomically.
+type StandardGaugeFloat64 struct {
+	bits uint64
+}
+
+// Snapshot returns a read-only copy of the gauge's current value.
+func (g *StandardGaugeFloat64) Snapshot() GaugeFloat64Snapshot {
+	return gaugeFloat64Snapshot(math.Float64frombits(atomic.LoadUint64(&g.bits)))
+}
+
+// Update sets the gauge's value.
+func (g *StandardGaugeFloat64) Update(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+// UpdateIfGt sets the gauge's value to v if v is greater than the current
+// value, retrying the compare-and-swap until it wins the race.
+func (g *StandardGaugeFloat64) UpdateIfGt(v float64) {
+	for {
+		currentBits := atomic.LoadUint64(&g.bits)
+		if v <= math.Float64frombits(currentBits) {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&g.bits, currentBits, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+// UpdateIfLt sets the gauge's value to v if v is less than the current
+// value, retrying the compare-and-swap until it wins the race.
+func (g *StandardGaugeFloat64) UpdateIfLt(v float64) {
+	for {
+		currentBits := atomic.LoadUint64(&g.bits)
+		if v >= math.Float64frombits(currentBits) {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&g.bits, currentBits, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+// gaugeFloat64Snapshot is a read-only copy of a GaugeFloat64's value.
+type gaugeFloat64Snapshot float64
+
+// Value returns the snapshotted value.
+func (g gaugeFloat64Snapshot) Value() float64 { return float64(g) }
@@ -0,0 +1,65 @@
+package metrics
+
+import "testing"
+
+func TestGaugeFloat64(t *testing.T) {
+	g := NewGaugeFloat64()
+	g.Update(47.0)
+	if value := g.Snapshot().Value(); value != 47.0 {
+		t.Errorf("Snapshot().Value() = %v, want 47.0", value)
+	}
+}
+
+func TestGaugeFloat64Snapshot(t *testing.T) {
+	g := NewGaugeFloat64()
+	g.Update(47.0)
+	snapshot := g.Snapshot()
+	g.Update(48.0)
+	if value := snapshot.Value(); value != 47.0 {
+		t.Errorf("Snapshot().Value() = %v, want 47.0 (unaffected by later Update)", value)
+	}
+}
+
+func TestGaugeFloat64UpdateIfGt(t *testing.T) {
+	g := NewGaugeFloat64()
+	g.Update(10.0)
+	g.UpdateIfGt(5.0)
+	if value := g.Snapshot().Value(); value != 10.0 {
+		t.Errorf("UpdateIfGt(5) after Update(10): Value() = %v, want 10.0", value)
+	}
+	g.UpdateIfGt(20.0)
+	if value := g.Snapshot().Value(); value != 20.0 {
+		t.Errorf("UpdateIfGt(20) after Update(10): Value() = %v, want 20.0", value)
+	}
+}
+
+func TestGaugeFloat64UpdateIfLt(t *testing.T) {
+	g := NewGaugeFloat64()
+	g.Update(10.0)
+	g.UpdateIfLt(20.0)
+	if value := g.Snapshot().Value(); value != 10.0 {
+		t.Errorf("UpdateIfLt(20) after Update(10): Value() = %v, want 10.0", value)
+	}
+	g.UpdateIfLt(5.0)
+	if value := g.Snapshot().Value(); value != 5.0 {
+		t.Errorf("UpdateIfLt(5) after Update(10): Value() = %v, want 5.0", value)
+	}
+}
+
+func TestGetOrRegisterGaugeFloat64(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredGaugeFloat64("foo", r).Update(47.0)
+	if value := GetOrRegisterGaugeFloat64("foo", r).Snapshot().Value(); value != 47.0 {
+		t.Errorf("GetOrRegisterGaugeFloat64().Snapshot().Value() = %v, want 47.0", value)
+	}
+}
+
+func TestNilGaugeFloat64(t *testing.T) {
+	g := NilGaugeFloat64{}
+	g.Update(1)
+	g.UpdateIfGt(2)
+	g.UpdateIfLt(0)
+	if value := g.Snapshot().Value(); value != 0 {
+		t.Errorf("Snapshot().Value() = %v, want 0", value)
+	}
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import "testing"
+
+func TestGauge(t *testing.T) {
+	g := NewGauge()
+	g.Update(47)
+	if value := g.Snapshot().Value(); value != 47 {
+		t.Errorf("Snapshot().Value() = %d, want 47", value)
+	}
+}
+
+func TestGaugeSnapshot(t *testing.T) {
+	g := NewGauge()
+	g.Update(47)
+	snapshot := g.Snapshot()
+	g.Update(48)
+	if value := snapshot.Value(); value != 47 {
+		t.Errorf("Snapshot().Value() = %d, want 47 (unaffected by later Update)", value)
+	}
+}
+
+func TestGaugeUpdateIfGt(t *testing.T) {
+	g := NewGauge()
+	g.Update(10)
+	g.UpdateIfGt(5)
+	if value := g.Snapshot().Value(); value != 10 {
+		t.Errorf("UpdateIfGt(5) after Update(10): Value() = %d, want 10", value)
+	}
+	g.UpdateIfGt(20)
+	if value := g.Snapshot().Value(); value != 20 {
+		t.Errorf("UpdateIfGt(20) after Update(10): Value() = %d, want 20", value)
+	}
+}
+
+func TestGaugeUpdateIfLt(t *testing.T) {
+	g := NewGauge()
+	g.Update(10)
+	g.UpdateIfLt(20)
+	if value := g.Snapshot().Value(); value != 10 {
+		t.Errorf("UpdateIfLt(20) after Update(10): Value() = %d, want 10", value)
+	}
+	g.UpdateIfLt(5)
+	if value := g.Snapshot().Value(); value != 5 {
+		t.Errorf("UpdateIfLt(5) after Update(10): Value() = %d, want 5", value)
+	}
+}
+
+func TestGetOrRegisterGauge(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredGauge("foo", r).Update(47)
+	if value := GetOrRegisterGauge("foo", r).Snapshot().Value(); value != 47 {
+		t.Errorf("GetOrRegisterGauge().Snapshot().Value() = %d, want 47", value)
+	}
+}
+
+func TestNilGauge(t *testing.T) {
+	g := NilGauge{}
+	g.Update(1)
+	g.UpdateIfGt(2)
+	g.UpdateIfLt(0)
+	if value := g.Snapshot().Value(); value != 0 {
+		t.Errorf("Snapshot().Value() = %d, want 0", value)
+	}
+}
@@ -0,0 +1,150 @@
+// Package graphite periodically flushes a metrics.Registry to a Graphite
+// (Carbon) server over the plaintext protocol.
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	metrics "github.com/weareyolo/go-metrics"
+)
+
+// Config holds the parameters for a Reporter.
+type Config struct {
+	Addr          string           // network address of the carbo server, e.g. "127.0.0.1:2003"
+	Registry      metrics.Registry // registry to flush
+	FlushInterval time.Duration    // how often to flush metrics
+	Prefix        string           // prefix to prepend to all metric names
+	Percentiles   []float64        // percentiles to report for histograms and timers
+	Clock         clock.Clock      // clock used to drive the flush loop; defaults to clock.New()
+	DialTimeout   time.Duration    // timeout used when opening the carbon connection
+}
+
+// Reporter pushes the contents of a metrics.Registry to Graphite on a fixed
+// interval.
+type Reporter struct {
+	cfg Config
+}
+
+// New returns a Reporter built from cfg, filling in defaults for any field
+// that was left zero.
+func New(cfg Config) *Reporter {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.New()
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = 60 * time.Second
+	}
+	if len(cfg.Percentiles) == 0 {
+		cfg.Percentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	return &Reporter{cfg: cfg}
+}
+
+// Graphite flushes r's registry to addr every d until ctx is cancelled,
+// logging (rather than returning) any error encountered along the way. It is
+// a convenience wrapper around New and Run for callers that don't need to
+// observe flush errors.
+func Graphite(ctx context.Context, r metrics.Registry, d time.Duration, prefix string, addr string) {
+	New(Config{Addr: addr, Registry: r, FlushInterval: d, Prefix: prefix}).Run(ctx)
+}
+
+// Run flushes the registry every FlushInterval until ctx is cancelled.
+func (r *Reporter) Run(ctx context.Context) {
+	ticker := r.cfg.Clock.Ticker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(ctx); err != nil {
+				log.Println("graphite:", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush performs a single synchronous push of every metric in the registry
+// to Graphite. It is exported so tests (and callers that want their own
+// scheduling) can trigger a push without waiting on FlushInterval.
+func (r *Reporter) Flush(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: r.cfg.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", r.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	now := r.cfg.Clock.Now().Unix()
+
+	r.cfg.Registry.Each(func(name string, i interface{}) {
+		switch metric := i.(type) {
+		case metrics.Counter:
+			snapshot := metric.Snapshot()
+			r.writeLine(w, name, "count", snapshot.Count(), now)
+		case metrics.Gauge:
+			snapshot := metric.Snapshot()
+			r.writeLine(w, name, "value", snapshot.Value(), now)
+		case metrics.GaugeFloat64:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(w, "%s.%s.value %f %d\n", r.cfg.Prefix, name, snapshot.Value(), now)
+		case metrics.Histogram:
+			snapshot := metric.Snapshot()
+			ps := snapshot.Percentiles(r.cfg.Percentiles)
+			r.writeLine(w, name, "count", snapshot.Count(), now)
+			r.writeLine(w, name, "min", snapshot.Min(), now)
+			r.writeLine(w, name, "max", snapshot.Max(), now)
+			fmt.Fprintf(w, "%s.%s.mean %f %d\n", r.cfg.Prefix, name, snapshot.Mean(), now)
+			r.writePercentiles(w, name, ps, now)
+		case metrics.Meter:
+			snapshot := metric.Snapshot()
+			r.writeLine(w, name, "count", snapshot.Count(), now)
+			fmt.Fprintf(w, "%s.%s.rate1 %f %d\n", r.cfg.Prefix, name, snapshot.Rate1(), now)
+			fmt.Fprintf(w, "%s.%s.rate5 %f %d\n", r.cfg.Prefix, name, snapshot.Rate5(), now)
+			fmt.Fprintf(w, "%s.%s.rate15 %f %d\n", r.cfg.Prefix, name, snapshot.Rate15(), now)
+		case metrics.Timer:
+			snapshot := metric.Snapshot()
+			ps := snapshot.Percentiles(r.cfg.Percentiles)
+			r.writeLine(w, name, "count", snapshot.Count(), now)
+			fmt.Fprintf(w, "%s.%s.mean %f %d\n", r.cfg.Prefix, name, snapshot.Mean(), now)
+			r.writePercentiles(w, name, ps, now)
+		case metrics.ResettingTimer:
+			snapshot := metric.Snapshot()
+			ps := snapshot.Percentiles(r.cfg.Percentiles)
+			fmt.Fprintf(w, "%s.%s.count %d %d\n", r.cfg.Prefix, name, snapshot.Count(), now)
+			fmt.Fprintf(w, "%s.%s.mean %f %d\n", r.cfg.Prefix, name, snapshot.Mean(), now)
+			for i, p := range r.cfg.Percentiles {
+				fmt.Fprintf(w, "%s.%s.%s %d %d\n", r.cfg.Prefix, name, percentileKey(p), ps[i], now)
+			}
+		}
+	})
+
+	return w.Flush()
+}
+
+func (r *Reporter) writeLine(w *bufio.Writer, name, field string, value int64, now int64) {
+	fmt.Fprintf(w, "%s.%s.%s %d %d\n", r.cfg.Prefix, name, field, value, now)
+}
+
+func (r *Reporter) writePercentiles(w *bufio.Writer, name string, ps []float64, now int64) {
+	for i, p := range r.cfg.Percentiles {
+		fmt.Fprintf(w, "%s.%s.%s %f %d\n", r.cfg.Prefix, name, percentileKey(p), ps[i], now)
+	}
+}
+
+// percentileKey renders a fraction like 0.999 as the Graphite path segment
+// "p99.9", distinct from 0.99's "p99".
+func percentileKey(p float64) string {
+	return "p" + strconv.FormatFloat(p*100, 'f', -1, 64)
+}
@@ -0,0 +1,95 @@
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	metrics "github.com/weareyolo/go-metrics"
+)
+
+// listen starts a TCP listener and returns its address along with a channel
+// that receives the plaintext body of the first connection it accepts.
+func listen(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var buf strings.Builder
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			buf.WriteString(scanner.Text())
+			buf.WriteByte('\n')
+		}
+		lines <- buf.String()
+	}()
+	return ln.Addr().String(), lines
+}
+
+func TestFlushWritesCounterAndGauge(t *testing.T) {
+	addr, lines := listen(t)
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("my.counter", r).Inc(3)
+	metrics.GetOrRegisterGauge("my.gauge", r).Update(7)
+
+	rep := New(Config{Addr: addr, Registry: r, Prefix: "test"})
+	if err := rep.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	out := <-lines
+	if !strings.Contains(out, "test.my.counter.count 3 ") {
+		t.Errorf("output = %q, want a test.my.counter.count 3 line", out)
+	}
+	if !strings.Contains(out, "test.my.gauge.value 7 ") {
+		t.Errorf("output = %q, want a test.my.gauge.value 7 line", out)
+	}
+}
+
+// TestFlushDistinguishesP99AndP999 guards against the label collision where
+// 0.99 and 0.999 both rendered as "p99" and overwrote each other at the same
+// Graphite path.
+func TestFlushDistinguishesP99AndP999(t *testing.T) {
+	addr, lines := listen(t)
+
+	r := metrics.NewRegistry()
+	h := metrics.GetOrRegisterHistogram("my.histogram", r, metrics.NewUniformSample(100))
+	for i := int64(1); i <= 1000; i++ {
+		h.Update(i)
+	}
+
+	rep := New(Config{Addr: addr, Registry: r, Prefix: "test", Percentiles: []float64{0.99, 0.999}})
+	if err := rep.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	out := <-lines
+	if !strings.Contains(out, "test.my.histogram.p99 ") {
+		t.Errorf("output = %q, missing p99 line", out)
+	}
+	if !strings.Contains(out, "test.my.histogram.p99.9 ") {
+		t.Errorf("output = %q, missing p99.9 line", out)
+	}
+}
+
+func TestFlushReturnsErrorOnDialFailure(t *testing.T) {
+	r := metrics.NewRegistry()
+	rep := New(Config{Addr: "127.0.0.1:0", Registry: r, DialTimeout: time.Millisecond})
+	if err := rep.Flush(context.Background()); err == nil {
+		t.Error("Flush() error = nil, want a dial error connecting to a closed port")
+	}
+}
@@ -0,0 +1,364 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// ErrIncompatibleSample is returned by Merge when the two samples don't
+// share the same bucket layout and so can't be combined element-wise.
+var ErrIncompatibleSample = errors.New("metrics: incompatible sample layout")
+
+// NewHDRSample constructs a Sample that stores counts in fixed logarithmic
+// buckets (as used by HdrHistogram) instead of keeping a reservoir of raw
+// int64s. Update is two atomic increments (the bucket and the total count)
+// with no allocation, memory is bounded (fixed by minValue, maxValue and
+// sigFigs, independent of how many values are recorded), and the
+// full range of recorded values is always represented rather than a
+// reservoir subsample. The tradeoff is that Min/Max/Percentiles only have
+// sub-bucket precision, controlled by sigFigs.
+//
+// minValue and maxValue bound the range of values that can be recorded
+// without loss; sigFigs (1-5) controls how many significant decimal digits
+// of precision are preserved within that range.
+func NewHDRSample(minValue, maxValue int64, sigFigs int) Sample {
+	if UseNilMetrics {
+		return NilSample{}
+	}
+	return newHDRSample(minValue, maxValue, sigFigs)
+}
+
+func newHDRSample(minValue, maxValue int64, sigFigs int) *HDRSample {
+	if sigFigs < 1 {
+		sigFigs = 1
+	}
+	if sigFigs > 5 {
+		sigFigs = 5
+	}
+	if minValue < 1 {
+		minValue = 1
+	}
+	if maxValue < minValue {
+		maxValue = minValue
+	}
+
+	// This mirrors HdrHistogram's own derivation of its bucket layout from
+	// (lowestDiscernibleValue, highestTrackableValue, significantFigures):
+	// pick a sub-bucket resolution fine enough to tell apart sigFigs
+	// decimal digits, then stack enough doubling-width buckets on top of
+	// it to cover the full range up to maxValue.
+	largestValueWithSingleUnitResolution := 2 * math.Pow10(sigFigs)
+	subBucketCountMagnitude := int(math.Ceil(math.Log2(largestValueWithSingleUnitResolution)))
+	subBucketHalfCountMagnitude := subBucketCountMagnitude - 1
+	if subBucketHalfCountMagnitude < 0 {
+		subBucketHalfCountMagnitude = 0
+	}
+	unitMagnitude := int(math.Floor(math.Log2(float64(minValue))))
+	if unitMagnitude < 0 {
+		unitMagnitude = 0
+	}
+	subBucketCount := 1 << uint(subBucketHalfCountMagnitude+1)
+	subBucketHalf := subBucketCount / 2
+
+	bucketCount := bucketsNeededToCover(maxValue, subBucketCount, unitMagnitude)
+	countsLen := (bucketCount + 1) * subBucketHalf
+
+	return &HDRSample{
+		minValue:                    minValue,
+		maxValue:                    maxValue,
+		sigFigs:                     sigFigs,
+		subBucketCount:              subBucketCount,
+		subBucketHalf:               subBucketHalf,
+		subBucketHalfCountMagnitude: subBucketHalfCountMagnitude,
+		unitMagnitude:               unitMagnitude,
+		bucketCount:                 bucketCount,
+		counts:                      make([]uint64, countsLen),
+	}
+}
+
+// bucketsNeededToCover returns the number of doubling-width buckets stacked
+// above the sub-bucket array needed so the bucket layout can represent
+// maxValue.
+func bucketsNeededToCover(maxValue int64, subBucketCount, unitMagnitude int) int {
+	smallestUntrackableValue := int64(subBucketCount) << uint(unitMagnitude)
+	bucketsNeeded := 1
+	for smallestUntrackableValue < maxValue {
+		if smallestUntrackableValue > math.MaxInt64/2 {
+			return bucketsNeeded + 1
+		}
+		smallestUntrackableValue <<= 1
+		bucketsNeeded++
+	}
+	return bucketsNeeded
+}
+
+// HDRSample is the standard implementation of an HDR-style Sample. All
+// state after construction is either immutable (the bucket layout) or
+// touched only via atomic operations, so Update never allocates and never
+// blocks.
+type HDRSample struct {
+	minValue, maxValue          int64
+	sigFigs                     int
+	subBucketCount              int
+	subBucketHalf               int
+	subBucketHalfCountMagnitude int
+	unitMagnitude               int
+	bucketCount                 int
+	counts                      []uint64
+	totalCount                  int64
+}
+
+// Clear zeroes every bucket's count.
+func (s *HDRSample) Clear() {
+	for i := range s.counts {
+		atomic.StoreUint64(&s.counts[i], 0)
+	}
+	atomic.StoreInt64(&s.totalCount, 0)
+}
+
+// Count returns the number of values recorded.
+func (s *HDRSample) Count() int64 {
+	return atomic.LoadInt64(&s.totalCount)
+}
+
+// Size returns the number of values recorded; HDRSample keeps full recall so
+// Size always equals Count, unlike a reservoir sample.
+func (s *HDRSample) Size() int {
+	return int(s.Count())
+}
+
+// Update records a value with an atomic increment of its bucket and of the
+// total count.
+// Values outside [minValue, maxValue] are clamped into the nearest bucket
+// rather than dropped, so Count always reflects every call to Update.
+func (s *HDRSample) Update(v int64) {
+	idx := s.countsIndexFor(v)
+	if idx < 0 {
+		idx = 0
+	} else if idx >= len(s.counts) {
+		idx = len(s.counts) - 1
+	}
+	atomic.AddUint64(&s.counts[idx], 1)
+	atomic.AddInt64(&s.totalCount, 1)
+}
+
+// Values expands the bucket counts back into per-bucket representative
+// values. This is lossy (only the bucket's value is recovered, not the
+// original raw value) and is provided to satisfy Sample; prefer Percentiles
+// for reporting.
+func (s *HDRSample) Values() []int64 {
+	values := make([]int64, 0, s.Count())
+	for i := range s.counts {
+		c := atomic.LoadUint64(&s.counts[i])
+		if c == 0 {
+			continue
+		}
+		v := s.valueFor(i)
+		for j := uint64(0); j < c; j++ {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Min returns the value of the lowest non-empty bucket.
+func (s *HDRSample) Min() int64 {
+	for i := range s.counts {
+		if atomic.LoadUint64(&s.counts[i]) > 0 {
+			return s.valueFor(i)
+		}
+	}
+	return 0
+}
+
+// Max returns the value of the highest non-empty bucket.
+func (s *HDRSample) Max() int64 {
+	for i := len(s.counts) - 1; i >= 0; i-- {
+		if atomic.LoadUint64(&s.counts[i]) > 0 {
+			return s.valueFor(i)
+		}
+	}
+	return 0
+}
+
+// Mean returns the count-weighted mean of the bucket values.
+func (s *HDRSample) Mean() float64 {
+	var total, weighted float64
+	for i := range s.counts {
+		c := atomic.LoadUint64(&s.counts[i])
+		if c == 0 {
+			continue
+		}
+		total += float64(c)
+		weighted += float64(s.valueFor(i)) * float64(c)
+	}
+	if total == 0 {
+		return 0
+	}
+	return weighted / total
+}
+
+// Sum returns the count-weighted sum of the bucket values.
+func (s *HDRSample) Sum() int64 {
+	var total int64
+	for i := range s.counts {
+		c := atomic.LoadUint64(&s.counts[i])
+		if c == 0 {
+			continue
+		}
+		total += s.valueFor(i) * int64(c)
+	}
+	return total
+}
+
+// StdDev returns the count-weighted standard deviation of the bucket
+// values.
+func (s *HDRSample) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Variance returns the count-weighted variance of the bucket values.
+func (s *HDRSample) Variance() float64 {
+	var total float64
+	for i := range s.counts {
+		total += float64(atomic.LoadUint64(&s.counts[i]))
+	}
+	if total == 0 {
+		return 0
+	}
+	mean := s.Mean()
+	var variance float64
+	for i := range s.counts {
+		c := float64(atomic.LoadUint64(&s.counts[i]))
+		if c == 0 {
+			continue
+		}
+		d := float64(s.valueFor(i)) - mean
+		variance += d * d * c
+	}
+	return variance / total
+}
+
+// Percentile returns a single percentile boundary.
+func (s *HDRSample) Percentile(p float64) float64 {
+	return s.Percentiles([]float64{p})[0]
+}
+
+// Percentiles returns, for each requested percentile, the (lower-edge) value
+// of the first bucket whose cumulative count reaches it; no interpolation is
+// done within the bucket.
+func (s *HDRSample) Percentiles(percentiles []float64) []float64 {
+	scores := make([]float64, len(percentiles))
+	total := s.Count()
+	if total == 0 {
+		return scores
+	}
+	for i, p := range percentiles {
+		target := p * float64(total)
+		var cumulative uint64
+		for b := range s.counts {
+			cumulative += atomic.LoadUint64(&s.counts[b])
+			if float64(cumulative) >= target {
+				scores[i] = float64(s.valueFor(b))
+				break
+			}
+		}
+	}
+	return scores
+}
+
+// Snapshot returns an independent copy of the sample's counts; because the
+// snapshot only needs to copy the count slice (not a reservoir of raw
+// values), it is cheap even for samples that have recorded millions of
+// updates.
+func (s *HDRSample) Snapshot() Sample {
+	counts := make([]uint64, len(s.counts))
+	for i := range s.counts {
+		counts[i] = atomic.LoadUint64(&s.counts[i])
+	}
+	return &HDRSample{
+		minValue:                    s.minValue,
+		maxValue:                    s.maxValue,
+		sigFigs:                     s.sigFigs,
+		subBucketCount:              s.subBucketCount,
+		subBucketHalf:               s.subBucketHalf,
+		subBucketHalfCountMagnitude: s.subBucketHalfCountMagnitude,
+		unitMagnitude:               s.unitMagnitude,
+		bucketCount:                 s.bucketCount,
+		counts:                      counts,
+		totalCount:                  s.Count(),
+	}
+}
+
+// Merge adds other's counts into s element-wise. other must be an
+// *HDRSample with the same bucket layout as s (as it is when constructed
+// with the same minValue, maxValue and sigFigs); otherwise Merge returns
+// ErrIncompatibleSample without modifying s.
+func (s *HDRSample) Merge(other Sample) error {
+	o, ok := other.(*HDRSample)
+	if !ok || len(o.counts) != len(s.counts) ||
+		o.subBucketCount != s.subBucketCount || o.unitMagnitude != s.unitMagnitude {
+		return ErrIncompatibleSample
+	}
+	var added int64
+	for i := range s.counts {
+		c := atomic.LoadUint64(&o.counts[i])
+		if c == 0 {
+			continue
+		}
+		atomic.AddUint64(&s.counts[i], c)
+		added += int64(c)
+	}
+	atomic.AddInt64(&s.totalCount, added)
+	return nil
+}
+
+// countsIndexFor returns the index into counts for the bucket that value v
+// falls into, following HdrHistogram's own (magnitude, subBucketIndex)
+// derivation: find the doubling-width bucket v falls in, then v's position
+// within that bucket's sub-bucket array.
+func (s *HDRSample) countsIndexFor(v int64) int {
+	if v < s.minValue {
+		v = s.minValue
+	}
+	bucketIdx := s.bucketIndexFor(v)
+	subBucketIdx := s.subBucketIndexFor(v, bucketIdx)
+	bucketBaseIdx := (bucketIdx + 1) << uint(s.subBucketHalfCountMagnitude)
+	offset := subBucketIdx - s.subBucketHalf
+	return bucketBaseIdx + offset
+}
+
+// bucketIndexFor returns the index of the doubling-width bucket containing
+// v: the smallest magnitude such that v fits within subBucketCount
+// sub-buckets of that magnitude.
+func (s *HDRSample) bucketIndexFor(v int64) int {
+	subBucketMask := int64(s.subBucketCount-1) << uint(s.unitMagnitude)
+	// pow2Ceiling is the bit length of v | subBucketMask, i.e. the exponent
+	// of the smallest power of two strictly greater than it.
+	pow2Ceiling := 64 - bits.LeadingZeros64(uint64(v|subBucketMask))
+	bucketIdx := pow2Ceiling - s.unitMagnitude - (s.subBucketHalfCountMagnitude + 1)
+	if bucketIdx < 0 {
+		bucketIdx = 0
+	}
+	return bucketIdx
+}
+
+// subBucketIndexFor returns v's index within bucketIdx's sub-bucket array.
+func (s *HDRSample) subBucketIndexFor(v int64, bucketIdx int) int {
+	shift := bucketIdx + s.unitMagnitude
+	return int(v >> uint(shift))
+}
+
+// valueFor reconstructs the representative (lower-edge) value of counts
+// index idx, inverting countsIndexFor.
+func (s *HDRSample) valueFor(idx int) int64 {
+	bucketIdx := idx>>uint(s.subBucketHalfCountMagnitude) - 1
+	subBucketIdx := idx%s.subBucketHalf + s.subBucketHalf
+	if bucketIdx < 0 {
+		bucketIdx = 0
+		subBucketIdx = idx
+	}
+	return int64(subBucketIdx) << uint(bucketIdx+s.unitMagnitude)
+}
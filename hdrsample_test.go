@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHDRSampleCount(t *testing.T) {
+	s := newHDRSample(1, 1000000, 3)
+	for i := 0; i < 100; i++ {
+		s.Update(int64(i))
+	}
+	if count := s.Count(); count != 100 {
+		t.Errorf("Count() = %d, want 100", count)
+	}
+	if size := s.Size(); size != 100 {
+		t.Errorf("Size() = %d, want 100", size)
+	}
+}
+
+func TestHDRSampleSum(t *testing.T) {
+	s := newHDRSample(1, 1000000, 3)
+	s.Update(100)
+	s.Update(200)
+	if sum := s.Sum(); sum < 290 || sum > 310 {
+		t.Errorf("Sum() = %d, want close to 300", sum)
+	}
+}
+
+// TestHDRSampleFullRangeRecall records a single value at many points across
+// [minValue, maxValue], each into a fresh sample, and asserts each one is
+// recovered (via Min/Max) within a 2% tolerance, rather than being misbucketed or
+// collapsed into the top bucket as happened before the bucket-index fix.
+func TestHDRSampleFullRangeRecall(t *testing.T) {
+	const minValue, maxValue = 1, 1000000
+	const sigFigs = 3
+
+	values := []int64{1, 10, 100, 1000, 5000, 10000, 16384, 50000, 100000, 500000, 999999, 1000000}
+	for _, v := range values {
+		s := newHDRSample(minValue, maxValue, sigFigs)
+		s.Update(v)
+
+		got := s.Min()
+		tolerance := int64(math.Ceil(float64(v) * 0.02))
+		if tolerance < 1 {
+			tolerance = 1
+		}
+		diff := got - v
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > tolerance {
+			t.Errorf("Update(%d): recovered value %d, want within %d (tolerance %d)", v, got, v, tolerance)
+		}
+		if s.Max() != got {
+			t.Errorf("Update(%d): Min() = %d, Max() = %d, want equal for a single recorded value", v, got, s.Max())
+		}
+	}
+}
+
+func TestHDRSamplePercentilesMonotonic(t *testing.T) {
+	s := newHDRSample(1, 1000000, 3)
+	for i := int64(1); i <= 10000; i++ {
+		s.Update(i)
+	}
+
+	ps := s.Percentiles([]float64{0.5, 0.75, 0.99})
+	for i := 1; i < len(ps); i++ {
+		if ps[i] < ps[i-1] {
+			t.Errorf("Percentiles() = %v, want non-decreasing", ps)
+		}
+	}
+	if p := s.Percentile(0.5); p != ps[0] {
+		t.Errorf("Percentile(0.5) = %v, want %v", p, ps[0])
+	}
+}
+
+func TestHDRSampleClear(t *testing.T) {
+	s := newHDRSample(1, 1000000, 3)
+	s.Update(42)
+	s.Clear()
+	if count := s.Count(); count != 0 {
+		t.Errorf("Count() after Clear() = %d, want 0", count)
+	}
+	if min := s.Min(); min != 0 {
+		t.Errorf("Min() after Clear() = %d, want 0", min)
+	}
+}
+
+func TestHDRSampleMerge(t *testing.T) {
+	a := newHDRSample(1, 1000000, 3)
+	b := newHDRSample(1, 1000000, 3)
+	a.Update(10)
+	b.Update(20)
+
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge() error = %v, want nil", err)
+	}
+	if count := a.Count(); count != 2 {
+		t.Errorf("Count() after Merge() = %d, want 2", count)
+	}
+
+	incompatible := newHDRSample(1, 100, 1)
+	if err := a.Merge(incompatible); err != ErrIncompatibleSample {
+		t.Errorf("Merge(incompatible) error = %v, want ErrIncompatibleSample", err)
+	}
+}
+
+func TestHDRSampleSnapshot(t *testing.T) {
+	s := newHDRSample(1, 1000000, 3)
+	s.Update(100)
+	snapshot := s.Snapshot()
+	s.Update(200)
+
+	if count := snapshot.Count(); count != 1 {
+		t.Errorf("Snapshot().Count() = %d, want 1 (unaffected by later Update)", count)
+	}
+}
@@ -0,0 +1,43 @@
+package metrics
+
+// Healthcheck is a metric that runs a check function on demand (typically
+// via Registry.RunHealthchecks) and records whether it passed.
+type Healthcheck interface {
+	Check()
+	Error() error
+	Healthy()
+	Unhealthy(error)
+}
+
+// NewHealthcheck constructs a new Healthcheck from the given check function.
+func NewHealthcheck(f func(Healthcheck)) Healthcheck {
+	return &StandardHealthcheck{f: f}
+}
+
+// StandardHealthcheck is the standard implementation of a Healthcheck.
+type StandardHealthcheck struct {
+	err error
+	f   func(Healthcheck)
+}
+
+// Check runs the healthcheck function, which must call Healthy or
+// Unhealthy on its own to report the outcome.
+func (h *StandardHealthcheck) Check() {
+	h.f(h)
+}
+
+// Error returns the error passed to the last call to Unhealthy, or nil if
+// the healthcheck has never failed.
+func (h *StandardHealthcheck) Error() error {
+	return h.err
+}
+
+// Healthy marks the healthcheck as passing.
+func (h *StandardHealthcheck) Healthy() {
+	h.err = nil
+}
+
+// Unhealthy marks the healthcheck as failing with the given error.
+func (h *StandardHealthcheck) Unhealthy(err error) {
+	h.err = err
+}
@@ -0,0 +1,96 @@
+package metrics
+
+// Histogram calculates distribution statistics from a Sample of int64s.
+type Histogram interface {
+	Clear()
+	Count() int64
+	Max() int64
+	Mean() float64
+	Min() int64
+	Percentile(float64) float64
+	Percentiles([]float64) []float64
+	Sample() Sample
+	Snapshot() Histogram
+	StdDev() float64
+	Sum() int64
+	Update(int64)
+	Variance() float64
+}
+
+// GetOrRegisterHistogram returns an existing Histogram or constructs and
+// registers a new StandardHistogram over s.
+func GetOrRegisterHistogram(name string, r Registry, s Sample) Histogram {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} { return NewHistogram(s) }).(Histogram)
+}
+
+// NewHistogram constructs a new StandardHistogram over the given Sample.
+func NewHistogram(s Sample) Histogram {
+	if UseNilMetrics {
+		return NilHistogram{}
+	}
+	return &StandardHistogram{sample: s}
+}
+
+// NewRegisteredHistogram constructs and registers a new StandardHistogram
+// over the given Sample.
+func NewRegisteredHistogram(name string, r Registry, s Sample) Histogram {
+	h := NewHistogram(s)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, h)
+	return h
+}
+
+// NilHistogram is a no-op Histogram.
+type NilHistogram struct{}
+
+func (NilHistogram) Clear()                             {}
+func (NilHistogram) Count() int64                       { return 0 }
+func (NilHistogram) Max() int64                         { return 0 }
+func (NilHistogram) Mean() float64                      { return 0.0 }
+func (NilHistogram) Min() int64                         { return 0 }
+func (NilHistogram) Percentile(float64) float64         { return 0.0 }
+func (NilHistogram) Percentiles(ps []float64) []float64 { return make([]float64, len(ps)) }
+func (NilHistogram) Sample() Sample                     { return NilSample{} }
+func (NilHistogram) Snapshot() Histogram                { return NilHistogram{} }
+func (NilHistogram) StdDev() float64                    { return 0.0 }
+func (NilHistogram) Sum() int64                         { return 0 }
+func (NilHistogram) Update(int64)                       {}
+func (NilHistogram) Variance() float64                  { return 0.0 }
+
+// StandardHistogram is the standard implementation of a Histogram: every
+// method other than Snapshot delegates straight to the underlying Sample.
+type StandardHistogram struct {
+	sample Sample
+}
+
+func (h *StandardHistogram) Clear()        { h.sample.Clear() }
+func (h *StandardHistogram) Count() int64  { return h.sample.Count() }
+func (h *StandardHistogram) Max() int64    { return h.sample.Max() }
+func (h *StandardHistogram) Mean() float64 { return h.sample.Mean() }
+func (h *StandardHistogram) Min() int64    { return h.sample.Min() }
+
+func (h *StandardHistogram) Percentile(p float64) float64 {
+	return h.sample.Percentile(p)
+}
+
+func (h *StandardHistogram) Percentiles(ps []float64) []float64 {
+	return h.sample.Percentiles(ps)
+}
+
+func (h *StandardHistogram) Sample() Sample { return h.sample }
+
+// Snapshot returns a histogram whose Sample is a read-only copy of this
+// histogram's Sample at the moment Snapshot was called.
+func (h *StandardHistogram) Snapshot() Histogram {
+	return &StandardHistogram{sample: h.sample.Snapshot()}
+}
+
+func (h *StandardHistogram) StdDev() float64   { return h.sample.StdDev() }
+func (h *StandardHistogram) Sum() int64        { return h.sample.Sum() }
+func (h *StandardHistogram) Update(v int64)    { h.sample.Update(v) }
+func (h *StandardHistogram) Variance() float64 { return h.sample.Variance() }
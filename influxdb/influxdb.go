@@ -0,0 +1,170 @@
+// Package influxdb periodically flushes a metrics.Registry to InfluxDB using
+// the HTTP line protocol write API.
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	metrics "github.com/weareyolo/go-metrics"
+)
+
+// Config holds the parameters for a Reporter.
+type Config struct {
+	URL           string            // e.g. "http://localhost:8086/write?db=metrics"
+	Registry      metrics.Registry  // registry to flush
+	FlushInterval time.Duration     // how often to flush metrics
+	Tags          map[string]string // tags applied to every point written
+	Percentiles   []float64         // percentiles to report for histograms and timers
+	Clock         clock.Clock       // clock used to drive the flush loop; defaults to clock.New()
+	Client        *http.Client      // HTTP client used to write points; defaults to http.DefaultClient
+}
+
+// Reporter pushes the contents of a metrics.Registry to InfluxDB on a fixed
+// interval.
+type Reporter struct {
+	cfg Config
+}
+
+// New returns a Reporter built from cfg, filling in defaults for any field
+// that was left zero.
+func New(cfg Config) *Reporter {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.New()
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = 60 * time.Second
+	}
+	if len(cfg.Percentiles) == 0 {
+		cfg.Percentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &Reporter{cfg: cfg}
+}
+
+// InfluxDB flushes r's registry to url every d until ctx is cancelled,
+// logging (rather than returning) any error encountered along the way.
+func InfluxDB(ctx context.Context, r metrics.Registry, d time.Duration, url string) {
+	New(Config{URL: url, Registry: r, FlushInterval: d}).Run(ctx)
+}
+
+// Run flushes the registry every FlushInterval until ctx is cancelled.
+func (r *Reporter) Run(ctx context.Context) {
+	ticker := r.cfg.Clock.Ticker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(ctx); err != nil {
+				log.Println("influxdb:", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush performs a single synchronous write of every metric in the registry
+// to InfluxDB. It is exported so tests (and callers that want their own
+// scheduling) can trigger a push without waiting on FlushInterval.
+func (r *Reporter) Flush(ctx context.Context) error {
+	now := r.cfg.Clock.Now()
+	var buf bytes.Buffer
+
+	r.cfg.Registry.Each(func(name string, i interface{}) {
+		tags := r.tagString()
+		switch metric := i.(type) {
+		case metrics.Counter:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(&buf, "%s%s count=%di %d\n", name, tags, snapshot.Count(), now.UnixNano())
+		case metrics.Gauge:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(&buf, "%s%s value=%di %d\n", name, tags, snapshot.Value(), now.UnixNano())
+		case metrics.GaugeFloat64:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(&buf, "%s%s value=%f %d\n", name, tags, snapshot.Value(), now.UnixNano())
+		case metrics.Histogram:
+			snapshot := metric.Snapshot()
+			ps := snapshot.Percentiles(r.cfg.Percentiles)
+			fmt.Fprintf(&buf, "%s%s count=%di,min=%di,max=%di,mean=%f%s %d\n",
+				name, tags, snapshot.Count(), snapshot.Min(), snapshot.Max(), snapshot.Mean(),
+				r.percentileFields(ps), now.UnixNano())
+		case metrics.Meter:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(&buf, "%s%s count=%di,rate1=%f,rate5=%f,rate15=%f %d\n",
+				name, tags, snapshot.Count(), snapshot.Rate1(), snapshot.Rate5(), snapshot.Rate15(), now.UnixNano())
+		case metrics.Timer:
+			snapshot := metric.Snapshot()
+			ps := snapshot.Percentiles(r.cfg.Percentiles)
+			fmt.Fprintf(&buf, "%s%s count=%di,mean=%f%s %d\n",
+				name, tags, snapshot.Count(), snapshot.Mean(), r.percentileFields(ps), now.UnixNano())
+		case metrics.ResettingTimer:
+			snapshot := metric.Snapshot()
+			ps := int64sToFloat64s(snapshot.Percentiles(r.cfg.Percentiles))
+			fmt.Fprintf(&buf, "%s%s count=%di,mean=%f%s %d\n",
+				name, tags, snapshot.Count(), snapshot.Mean(), r.percentileFields(ps), now.UnixNano())
+		}
+	})
+
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL, &buf)
+	if err != nil {
+		return err
+	}
+	resp, err := r.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("influxdb: write failed with status %s", resp.Status)
+	}
+	return nil
+}
+
+func (r *Reporter) tagString() string {
+	if len(r.cfg.Tags) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	for k, v := range r.cfg.Tags {
+		fmt.Fprintf(&buf, ",%s=%s", k, v)
+	}
+	return buf.String()
+}
+
+func (r *Reporter) percentileFields(ps []float64) string {
+	var buf bytes.Buffer
+	for i, p := range r.cfg.Percentiles {
+		fmt.Fprintf(&buf, ",%s=%f", percentileKey(p), ps[i])
+	}
+	return buf.String()
+}
+
+// percentileKey renders a fraction like 0.999 as the field key "p99.9",
+// distinct from 0.99's "p99".
+func percentileKey(p float64) string {
+	return "p" + strconv.FormatFloat(p*100, 'f', -1, 64)
+}
+
+// int64sToFloat64s converts ResettingTimer.Percentiles' []int64 boundaries
+// into the []float64 shape percentileFields expects, the same conversion
+// exp.snapshotAll does for the same case.
+func int64sToFloat64s(in []int64) []float64 {
+	out := make([]float64, len(in))
+	for i, v := range in {
+		out[i] = float64(v)
+	}
+	return out
+}
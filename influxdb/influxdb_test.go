@@ -0,0 +1,84 @@
+package influxdb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metrics "github.com/weareyolo/go-metrics"
+)
+
+func TestFlushWritesCounterAndGauge(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("my.counter", r).Inc(3)
+	metrics.GetOrRegisterGauge("my.gauge", r).Update(7)
+
+	rep := New(Config{URL: srv.URL, Registry: r})
+	if err := rep.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if !strings.Contains(body, "my.counter count=3i") {
+		t.Errorf("body = %q, want a my.counter count=3i field", body)
+	}
+	if !strings.Contains(body, "my.gauge value=7i") {
+		t.Errorf("body = %q, want a my.gauge value=7i field", body)
+	}
+}
+
+// TestFlushDistinguishesP99AndP999 guards against the label collision where
+// 0.99 and 0.999 both rendered as "p99" and overwrote each other in the same
+// InfluxDB line.
+func TestFlushDistinguishesP99AndP999(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	r := metrics.NewRegistry()
+	h := metrics.GetOrRegisterHistogram("my.histogram", r, metrics.NewUniformSample(100))
+	for i := int64(1); i <= 1000; i++ {
+		h.Update(i)
+	}
+
+	rep := New(Config{URL: srv.URL, Registry: r, Percentiles: []float64{0.99, 0.999}})
+	if err := rep.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if !strings.Contains(body, "p99=") {
+		t.Errorf("body = %q, missing p99 field", body)
+	}
+	if !strings.Contains(body, "p99.9=") {
+		t.Errorf("body = %q, missing p99.9 field", body)
+	}
+}
+
+func TestFlushReturnsErrorOnNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("my.counter", r).Inc(1)
+
+	rep := New(Config{URL: srv.URL, Registry: r})
+	if err := rep.Flush(context.Background()); err == nil {
+		t.Error("Flush() error = nil, want an error on a 500 response")
+	}
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Meter counts events and derives their rate over time, reporting 1/5/15
+// minute moving averages in addition to the mean rate since the meter was
+// created.
+type Meter interface {
+	Count() int64
+	Mark(int64)
+	Rate1() float64
+	Rate5() float64
+	Rate15() float64
+	RateMean() float64
+	Snapshot() Meter
+	Stop()
+}
+
+// GetOrRegisterMeter returns an existing Meter or constructs and registers a
+// new StandardMeter.
+func GetOrRegisterMeter(name string, r Registry) Meter {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewMeter).(Meter)
+}
+
+// NewMeter constructs a new StandardMeter and starts the goroutine that
+// ticks its EWMAs every 5 seconds.
+func NewMeter() Meter {
+	if UseNilMetrics {
+		return NilMeter{}
+	}
+	m := &StandardMeter{
+		rate1:    NewEWMA1(),
+		rate5:    NewEWMA5(),
+		rate15:   NewEWMA15(),
+		start:    time.Now(),
+		stopChan: make(chan struct{}),
+	}
+	go m.tickLoop()
+	return m
+}
+
+// NewRegisteredMeter constructs and registers a new StandardMeter.
+func NewRegisteredMeter(name string, r Registry) Meter {
+	m := NewMeter()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, m)
+	return m
+}
+
+// NilMeter is a no-op Meter.
+type NilMeter struct{}
+
+func (NilMeter) Count() int64      { return 0 }
+func (NilMeter) Mark(n int64)      {}
+func (NilMeter) Rate1() float64    { return 0.0 }
+func (NilMeter) Rate5() float64    { return 0.0 }
+func (NilMeter) Rate15() float64   { return 0.0 }
+func (NilMeter) RateMean() float64 { return 0.0 }
+func (NilMeter) Snapshot() Meter   { return NilMeter{} }
+func (NilMeter) Stop()             {}
+
+// StandardMeter is the standard implementation of a Meter.
+type StandardMeter struct {
+	mutex    sync.Mutex
+	count    int64
+	rate1    EWMA
+	rate5    EWMA
+	rate15   EWMA
+	start    time.Time
+	stopChan chan struct{}
+	stopped  bool
+}
+
+// Count returns the total number of events recorded.
+func (m *StandardMeter) Count() int64 {
+	return atomic.LoadInt64(&m.count)
+}
+
+// Mark records n events.
+func (m *StandardMeter) Mark(n int64) {
+	atomic.AddInt64(&m.count, n)
+	m.rate1.Update(n)
+	m.rate5.Update(n)
+	m.rate15.Update(n)
+}
+
+// Rate1 returns the 1-minute moving average rate of events per second.
+func (m *StandardMeter) Rate1() float64 { return m.rate1.Rate() }
+
+// Rate5 returns the 5-minute moving average rate of events per second.
+func (m *StandardMeter) Rate5() float64 { return m.rate5.Rate() }
+
+// Rate15 returns the 15-minute moving average rate of events per second.
+func (m *StandardMeter) Rate15() float64 { return m.rate15.Rate() }
+
+// RateMean returns the mean rate of events per second since the meter was
+// created.
+func (m *StandardMeter) RateMean() float64 {
+	elapsed := time.Since(m.start).Seconds()
+	if elapsed <= 0 {
+		return 0.0
+	}
+	return float64(m.Count()) / elapsed
+}
+
+// Snapshot returns a read-only copy of the meter's current counts and
+// rates.
+func (m *StandardMeter) Snapshot() Meter {
+	return &meterSnapshot{
+		count:    m.Count(),
+		rate1:    m.Rate1(),
+		rate5:    m.Rate5(),
+		rate15:   m.Rate15(),
+		rateMean: m.RateMean(),
+	}
+}
+
+// Stop halts the background goroutine that ticks the meter's EWMAs. A
+// stopped meter's rates no longer decay towards zero on inactivity.
+func (m *StandardMeter) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if !m.stopped {
+		m.stopped = true
+		close(m.stopChan)
+	}
+}
+
+func (m *StandardMeter) tickLoop() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.rate1.Tick()
+			m.rate5.Tick()
+			m.rate15.Tick()
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// meterSnapshot is a read-only copy of a Meter's counts and rates.
+type meterSnapshot struct {
+	count                          int64
+	rate1, rate5, rate15, rateMean float64
+}
+
+func (m *meterSnapshot) Count() int64      { return m.count }
+func (m *meterSnapshot) Mark(int64)        { panic("metrics: Mark called on a meterSnapshot") }
+func (m *meterSnapshot) Rate1() float64    { return m.rate1 }
+func (m *meterSnapshot) Rate5() float64    { return m.rate5 }
+func (m *meterSnapshot) Rate15() float64   { return m.rate15 }
+func (m *meterSnapshot) RateMean() float64 { return m.rateMean }
+func (m *meterSnapshot) Snapshot() Meter   { return m }
+func (m *meterSnapshot) Stop()             {}
@@ -0,0 +1,47 @@
+// Package metrics provides general-purpose counters, gauges, histograms,
+// meters and timers, along with a registry to collect them and reporters to
+// publish them to external systems.
+package metrics
+
+// UseNilMetrics is checked by the constructor functions for all of the
+// standard metrics (NewCounter, NewGauge, ...). If it is true, those
+// constructors return a no-op stub instead of a working metric, which is
+// useful for disabling metrics collection globally (e.g. in tests) without
+// touching every call site.
+var UseNilMetrics = false
+
+// DefaultRegistry is the registry used by the package-level convenience
+// functions (Register, GetOrRegister, Each, ...) and by the GetOrRegister*
+// family of constructors when passed a nil Registry.
+var DefaultRegistry = NewRegistry()
+
+// Each calls f for every metric in DefaultRegistry.
+func Each(f func(string, interface{})) {
+	DefaultRegistry.Each(f)
+}
+
+// Get returns the metric registered under name in DefaultRegistry, or nil.
+func Get(name string) interface{} {
+	return DefaultRegistry.Get(name)
+}
+
+// GetOrRegister returns the metric registered under name in DefaultRegistry,
+// registering metric under that name first if none exists yet.
+func GetOrRegister(name string, metric interface{}) interface{} {
+	return DefaultRegistry.GetOrRegister(name, metric)
+}
+
+// Register adds metric under name to DefaultRegistry.
+func Register(name string, metric interface{}) error {
+	return DefaultRegistry.Register(name, metric)
+}
+
+// RunHealthchecks runs every healthcheck registered in DefaultRegistry.
+func RunHealthchecks() {
+	DefaultRegistry.RunHealthchecks()
+}
+
+// Unregister removes the metric registered under name from DefaultRegistry.
+func Unregister(name string) {
+	DefaultRegistry.Unregister(name)
+}
@@ -81,7 +81,7 @@ func Example() {
 	t.Time(func() {})
 	t.Update(1)
 
-	fmt.Println(c.Count())
+	fmt.Println(c.Snapshot().Count())
 	fmt.Println(t.Min())
 	// Output: 17
 	// 1
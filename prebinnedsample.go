@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+)
+
+// PreBinnedSample is a Sample whose values arrive already grouped into fixed
+// buckets with known edges, such as the histograms exposed by Go's
+// runtime/metrics package. Unlike ExpDecaySample/UniformSample it never sees
+// individual raw values: callers add counts to a bucket directly via
+// UpdateBucket, and all statistics are derived from the bucket edges and
+// counts. Update panics because a pre-binned sample has no well-defined
+// bucket for an arbitrary raw value; use UpdateBucket instead.
+type PreBinnedSample struct {
+	mutex   sync.Mutex
+	buckets []float64 // len(buckets) == len(counts)+1, ascending; may end in +Inf
+	counts  []uint64  // counts[i] observed in [buckets[i], buckets[i+1])
+}
+
+// NewPreBinnedSample constructs a PreBinnedSample over the given bucket
+// edges. buckets must be sorted ascending and have at least two elements.
+func NewPreBinnedSample(buckets []float64) *PreBinnedSample {
+	edges := make([]float64, len(buckets))
+	copy(edges, buckets)
+	return &PreBinnedSample{
+		buckets: edges,
+		counts:  make([]uint64, len(edges)-1),
+	}
+}
+
+// UpdateBucket adds delta observations to bucket i. This is how a cumulative
+// runtime/metrics Float64Histogram's Counts[] gets translated into
+// incremental updates between two captures.
+func (s *PreBinnedSample) UpdateBucket(i int, delta uint64) {
+	s.mutex.Lock()
+	s.counts[i] += delta
+	s.mutex.Unlock()
+}
+
+// Update panics: a PreBinnedSample only accepts pre-bucketed counts via
+// UpdateBucket.
+func (s *PreBinnedSample) Update(int64) {
+	panic("metrics: PreBinnedSample.Update called; use UpdateBucket")
+}
+
+// Clear zeroes every bucket's count.
+func (s *PreBinnedSample) Clear() {
+	s.mutex.Lock()
+	for i := range s.counts {
+		s.counts[i] = 0
+	}
+	s.mutex.Unlock()
+}
+
+// Count returns the total number of observations across all buckets.
+func (s *PreBinnedSample) Count() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count()
+}
+
+func (s *PreBinnedSample) count() int64 {
+	var total uint64
+	for _, c := range s.counts {
+		total += c
+	}
+	return int64(total)
+}
+
+// Size returns the number of observations, matching Count; a pre-binned
+// sample has no reservoir capacity to report separately.
+func (s *PreBinnedSample) Size() int {
+	return int(s.Count())
+}
+
+// Min returns the lower edge of the lowest non-empty bucket.
+func (s *PreBinnedSample) Min() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, c := range s.counts {
+		if c > 0 {
+			return int64(s.buckets[i])
+		}
+	}
+	return 0
+}
+
+// Max returns the upper edge of the highest non-empty bucket.
+func (s *PreBinnedSample) Max() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := len(s.counts) - 1; i >= 0; i-- {
+		if s.counts[i] > 0 {
+			return int64(s.buckets[i+1])
+		}
+	}
+	return 0
+}
+
+// Mean returns the count-weighted mean of the bucket midpoints.
+func (s *PreBinnedSample) Mean() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var total, weighted float64
+	for i, c := range s.counts {
+		if c == 0 {
+			continue
+		}
+		mid := s.midpoint(i)
+		total += float64(c)
+		weighted += mid * float64(c)
+	}
+	if total == 0 {
+		return 0
+	}
+	return weighted / total
+}
+
+// Sum returns the count-weighted sum of the bucket midpoints.
+func (s *PreBinnedSample) Sum() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var total float64
+	for i, c := range s.counts {
+		if c == 0 {
+			continue
+		}
+		total += s.midpoint(i) * float64(c)
+	}
+	return int64(total)
+}
+
+// StdDev returns the count-weighted standard deviation of the bucket
+// midpoints.
+func (s *PreBinnedSample) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Variance returns the count-weighted variance of the bucket midpoints.
+func (s *PreBinnedSample) Variance() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var total float64
+	for _, c := range s.counts {
+		total += float64(c)
+	}
+	if total == 0 {
+		return 0
+	}
+	var mean float64
+	for i, c := range s.counts {
+		mean += s.midpoint(i) * float64(c)
+	}
+	mean /= total
+	var variance float64
+	for i, c := range s.counts {
+		d := s.midpoint(i) - mean
+		variance += d * d * float64(c)
+	}
+	return variance / total
+}
+
+// Values expands the bucket counts back into midpoint-valued samples. This
+// is lossy (the original raw values are gone) but lets PreBinnedSample
+// satisfy callers that want a flat slice. The slice holds one entry per
+// observation, so its length is Count(), not the number of buckets.
+func (s *PreBinnedSample) Values() []int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	values := make([]int64, 0, s.count())
+	for i, c := range s.counts {
+		mid := int64(s.midpoint(i))
+		for j := uint64(0); j < c; j++ {
+			values = append(values, mid)
+		}
+	}
+	return values
+}
+
+// Percentile returns a single percentile boundary.
+func (s *PreBinnedSample) Percentile(p float64) float64 {
+	return s.Percentiles([]float64{p})[0]
+}
+
+// Percentiles returns, for each requested percentile, the midpoint of the
+// first bucket whose cumulative count reaches it; no interpolation is done
+// within the bucket.
+func (s *PreBinnedSample) Percentiles(percentiles []float64) []float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	scores := make([]float64, len(percentiles))
+	total := s.count()
+	if total == 0 {
+		return scores
+	}
+	for i, p := range percentiles {
+		target := p * float64(total)
+		var cumulative uint64
+		for b, c := range s.counts {
+			cumulative += c
+			if float64(cumulative) >= target {
+				scores[i] = s.midpoint(b)
+				break
+			}
+		}
+	}
+	return scores
+}
+
+// Snapshot returns a copy of the sample's state that won't be mutated by
+// further UpdateBucket calls.
+func (s *PreBinnedSample) Snapshot() Sample {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	counts := make([]uint64, len(s.counts))
+	copy(counts, s.counts)
+	return &PreBinnedSample{buckets: s.buckets, counts: counts}
+}
+
+func (s *PreBinnedSample) midpoint(bucket int) float64 {
+	lo, hi := s.buckets[bucket], s.buckets[bucket+1]
+	if math.IsInf(hi, 1) {
+		return lo
+	}
+	return (lo + hi) / 2
+}
@@ -0,0 +1,157 @@
+// Package prometheus periodically pushes a metrics.Registry to a Prometheus
+// Pushgateway using the text exposition format.
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	metrics "github.com/weareyolo/go-metrics"
+)
+
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+// Config holds the parameters for a Reporter.
+type Config struct {
+	PushURL       string           // Pushgateway URL to POST the exposition text to
+	Registry      metrics.Registry // registry to flush
+	FlushInterval time.Duration    // how often to flush metrics
+	Namespace     string           // prefix applied to every metric name
+	Percentiles   []float64        // percentiles to report for histograms and timers
+	Clock         clock.Clock      // clock used to drive the flush loop; defaults to clock.New()
+	Client        *http.Client     // HTTP client used to push the exposition text; defaults to http.DefaultClient
+}
+
+// Reporter pushes the contents of a metrics.Registry to a Prometheus
+// Pushgateway on a fixed interval.
+type Reporter struct {
+	cfg Config
+}
+
+// New returns a Reporter built from cfg, filling in defaults for any field
+// that was left zero.
+func New(cfg Config) *Reporter {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.New()
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = 60 * time.Second
+	}
+	if len(cfg.Percentiles) == 0 {
+		cfg.Percentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &Reporter{cfg: cfg}
+}
+
+// Run flushes the registry every FlushInterval until ctx is cancelled.
+func (r *Reporter) Run(ctx context.Context) {
+	ticker := r.cfg.Clock.Ticker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(ctx); err != nil {
+				log.Println("prometheus:", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush renders every metric in the registry as Prometheus text exposition
+// format and pushes it to PushURL. It is exported so tests (and callers that
+// want their own scheduling) can trigger a push without waiting on
+// FlushInterval.
+func (r *Reporter) Flush(ctx context.Context) error {
+	var buf bytes.Buffer
+	WriteText(&buf, r.cfg.Registry, r.cfg.Namespace, r.cfg.Percentiles)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.PushURL, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := r.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("prometheus: push failed with status %s", resp.Status)
+	}
+	return nil
+}
+
+// WriteText renders every metric in r under namespace as Prometheus text
+// exposition format, so it can be reused by both the pushgateway Reporter
+// and a pull-based /metrics http.Handler.
+func WriteText(w *bytes.Buffer, r metrics.Registry, namespace string, percentiles []float64) {
+	r.Each(func(name string, i interface{}) {
+		metricName := sanitize(namespace, name)
+		switch metric := i.(type) {
+		case metrics.Counter:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(w, "# TYPE %s counter\n%s %d\n", metricName, metricName, snapshot.Count())
+		case metrics.Gauge:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(w, "# TYPE %s gauge\n%s %d\n", metricName, metricName, snapshot.Value())
+		case metrics.GaugeFloat64:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(w, "# TYPE %s gauge\n%s %f\n", metricName, metricName, snapshot.Value())
+		case metrics.Histogram:
+			snapshot := metric.Snapshot()
+			writeSummary(w, metricName, snapshot.Count(), snapshot.Sum(), snapshot.Percentiles(percentiles), percentiles)
+		case metrics.Meter:
+			snapshot := metric.Snapshot()
+			fmt.Fprintf(w, "# TYPE %s counter\n%s %d\n", metricName, metricName, snapshot.Count())
+		case metrics.Timer:
+			snapshot := metric.Snapshot()
+			writeSummary(w, metricName, snapshot.Count(), snapshot.Sum(), snapshot.Percentiles(percentiles), percentiles)
+		case metrics.ResettingTimer:
+			snapshot := metric.Snapshot()
+			ps := int64sToFloat64s(snapshot.Percentiles(percentiles))
+			var sum int64
+			for _, v := range snapshot.Values() {
+				sum += v
+			}
+			writeSummary(w, metricName, int64(snapshot.Count()), sum, ps, percentiles)
+		}
+	})
+}
+
+// int64sToFloat64s converts ResettingTimer.Percentiles' []int64 boundaries
+// into the []float64 shape writeSummary expects, the same conversion
+// exp.snapshotAll does for the same case.
+func int64sToFloat64s(in []int64) []float64 {
+	out := make([]float64, len(in))
+	for i, v := range in {
+		out[i] = float64(v)
+	}
+	return out
+}
+
+func writeSummary(w *bytes.Buffer, name string, count int64, sum int64, ps []float64, percentiles []float64) {
+	fmt.Fprintf(w, "# TYPE %s summary\n", name)
+	for i, p := range percentiles {
+		fmt.Fprintf(w, "%s{quantile=\"%g\"} %f\n", name, p, ps[i])
+	}
+	fmt.Fprintf(w, "%s_sum %d\n", name, sum)
+	fmt.Fprintf(w, "%s_count %d\n", name, count)
+}
+
+func sanitize(namespace, name string) string {
+	if namespace != "" {
+		name = namespace + "_" + name
+	}
+	return invalidChars.ReplaceAllString(name, "_")
+}
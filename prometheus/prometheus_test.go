@@ -0,0 +1,95 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metrics "github.com/weareyolo/go-metrics"
+)
+
+func TestWriteTextCounterAndGauge(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("my_counter", r).Inc(3)
+	metrics.GetOrRegisterGauge("my_gauge", r).Update(7)
+
+	var buf bytes.Buffer
+	WriteText(&buf, r, "", []float64{0.5, 0.99})
+
+	out := buf.String()
+	if !strings.Contains(out, "my_counter 3\n") {
+		t.Errorf("output = %q, want a my_counter 3 line", out)
+	}
+	if !strings.Contains(out, "my_gauge 7\n") {
+		t.Errorf("output = %q, want a my_gauge 7 line", out)
+	}
+}
+
+func TestWriteTextSanitizesNameAndAppliesNamespace(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("my.counter-name", r).Inc(1)
+
+	var buf bytes.Buffer
+	WriteText(&buf, r, "ns", []float64{0.5, 0.99})
+
+	if !strings.Contains(buf.String(), "ns_my_counter_name 1\n") {
+		t.Errorf("output = %q, want sanitized namespaced name", buf.String())
+	}
+}
+
+func TestWriteTextHistogramQuantiles(t *testing.T) {
+	r := metrics.NewRegistry()
+	h := metrics.GetOrRegisterHistogram("my_histogram", r, metrics.NewUniformSample(100))
+	for i := int64(1); i <= 1000; i++ {
+		h.Update(i)
+	}
+
+	var buf bytes.Buffer
+	WriteText(&buf, r, "", []float64{0.99, 0.999})
+
+	out := buf.String()
+	if !strings.Contains(out, `quantile="0.99"`) {
+		t.Errorf("output = %q, missing quantile=\"0.99\"", out)
+	}
+	if !strings.Contains(out, `quantile="0.999"`) {
+		t.Errorf("output = %q, missing quantile=\"0.999\"", out)
+	}
+}
+
+func TestFlushPushesToGateway(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("my_counter", r).Inc(5)
+
+	rep := New(Config{PushURL: srv.URL, Registry: r})
+	if err := rep.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if !strings.Contains(body, "my_counter 5\n") {
+		t.Errorf("body = %q, want a my_counter 5 line", body)
+	}
+}
+
+func TestFlushReturnsErrorOnNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	r := metrics.NewRegistry()
+	rep := New(Config{PushURL: srv.URL, Registry: r})
+	if err := rep.Flush(context.Background()); err == nil {
+		t.Error("Flush() error = nil, want an error on a 500 response")
+	}
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Registry holds named metrics (and, potentially, healthchecks) and exposes
+// them for iteration by reporters.
+type Registry interface {
+	// Each calls f for every metric currently registered.
+	Each(func(string, interface{}))
+	// Get returns the metric registered under name, or nil if none exists.
+	Get(name string) interface{}
+	// GetOrRegister returns the metric registered under name, registering
+	// metricOrGetter (or the value it returns, if it's a func() interface{})
+	// under that name first if none exists yet.
+	GetOrRegister(name string, metricOrGetter interface{}) interface{}
+	// Register adds metric under name, returning an error if name is
+	// already registered.
+	Register(name string, metric interface{}) error
+	// RunHealthchecks runs every healthcheck registered.
+	RunHealthchecks()
+	// Unregister removes the metric registered under name, if any.
+	Unregister(name string)
+}
+
+// StandardRegistry is the standard implementation of a Registry. It is safe
+// for concurrent use.
+type StandardRegistry struct {
+	metrics sync.Map
+}
+
+// NewRegistry constructs a new StandardRegistry.
+func NewRegistry() Registry {
+	return &StandardRegistry{}
+}
+
+// Each calls f for every metric currently registered.
+func (r *StandardRegistry) Each(f func(string, interface{})) {
+	r.metrics.Range(func(k, v interface{}) bool {
+		f(k.(string), v)
+		return true
+	})
+}
+
+// Get returns the metric registered under name, or nil if none exists.
+func (r *StandardRegistry) Get(name string) interface{} {
+	v, _ := r.metrics.Load(name)
+	return v
+}
+
+// GetOrRegister returns the metric registered under name, registering
+// metricOrGetter under that name first if none exists yet.
+func (r *StandardRegistry) GetOrRegister(name string, metricOrGetter interface{}) interface{} {
+	if v, ok := r.metrics.Load(name); ok {
+		return v
+	}
+	v := resolve(metricOrGetter)
+	actual, _ := r.metrics.LoadOrStore(name, v)
+	return actual
+}
+
+// Register adds metric under name, returning an error if name is already
+// registered.
+func (r *StandardRegistry) Register(name string, metric interface{}) error {
+	if _, loaded := r.metrics.LoadOrStore(name, resolve(metric)); loaded {
+		return fmt.Errorf("metrics: %q is already registered", name)
+	}
+	return nil
+}
+
+// RunHealthchecks runs every registered Healthcheck.
+func (r *StandardRegistry) RunHealthchecks() {
+	r.metrics.Range(func(_, v interface{}) bool {
+		if h, ok := v.(Healthcheck); ok {
+			h.Check()
+		}
+		return true
+	})
+}
+
+// Unregister removes the metric registered under name, if any.
+func (r *StandardRegistry) Unregister(name string) {
+	r.metrics.Delete(name)
+}
+
+// resolve returns metricOrGetter itself, unless it is a niladic function
+// (e.g. NewCounter, or func() interface{}), in which case it calls it and
+// returns the result. This is what lets GetOrRegister accept either a
+// ready-made metric or a constructor such as NewCounter.
+func resolve(metricOrGetter interface{}) interface{} {
+	v := reflect.ValueOf(metricOrGetter)
+	if v.Kind() == reflect.Func && v.Type().NumIn() == 0 && v.Type().NumOut() == 1 {
+		return v.Call(nil)[0].Interface()
+	}
+	return metricOrGetter
+}
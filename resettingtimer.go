@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ResettingTimer is used for storing aggregated data describing durations
+// recorded since the last flush. Unlike Timer, which keeps a decaying
+// reservoir so percentiles stay cheap to read at any time, ResettingTimer
+// keeps every raw duration recorded since the last Snapshot and computes
+// min/max/mean/percentiles on demand, discarding the slice afterwards. That
+// makes Update essentially free and is the right tradeoff for reporters that
+// flush on a fixed interval (seconds, not microseconds) rather than for code
+// that reads percentiles continuously.
+type ResettingTimer interface {
+	Values() []int64
+	Snapshot() ResettingTimerSnapshot
+	Percentiles([]float64) []int64
+	Mean() float64
+	Time(func())
+	Update(time.Duration)
+	UpdateSince(time.Time)
+}
+
+// NilResettingTimer is a no-op ResettingTimer.
+type NilResettingTimer struct {
+	values []int64
+}
+
+// NewResettingTimer constructs a new StandardResettingTimer.
+func NewResettingTimer() ResettingTimer {
+	if UseNilMetrics {
+		return NilResettingTimer{}
+	}
+	return &StandardResettingTimer{
+		values: make([]int64, 0, 10),
+	}
+}
+
+func (NilResettingTimer) Values() []int64 { return nil }
+
+func (n NilResettingTimer) Snapshot() ResettingTimerSnapshot {
+	return &resettingTimerSnapshot{values: n.values}
+}
+
+func (NilResettingTimer) Percentiles(percentiles []float64) []int64 {
+	return make([]int64, len(percentiles))
+}
+
+func (NilResettingTimer) Mean() float64         { return 0.0 }
+func (NilResettingTimer) Time(f func())         { f() }
+func (NilResettingTimer) Update(time.Duration)  {}
+func (NilResettingTimer) UpdateSince(time.Time) {}
+
+// StandardResettingTimer is the standard implementation of a ResettingTimer.
+// It stores raw durations (as int64 nanoseconds) recorded since the last
+// Snapshot and clears them once read.
+type StandardResettingTimer struct {
+	mutex  sync.Mutex
+	values []int64
+}
+
+// Values returns a slice of durations recorded since the last Snapshot.
+func (t *StandardResettingTimer) Values() []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.values
+}
+
+// Snapshot resets the timer and returns a read-only copy of its state prior
+// to the reset.
+func (t *StandardResettingTimer) Snapshot() ResettingTimerSnapshot {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	currentValues := t.values
+	t.values = make([]int64, 0, 10)
+	return &resettingTimerSnapshot{values: currentValues}
+}
+
+// Percentiles returns the boundaries for the input percentiles computed from
+// the values recorded so far, without resetting.
+func (t *StandardResettingTimer) Percentiles(percentiles []float64) []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return calculateResettingTimerPercentiles(t.values, percentiles)
+}
+
+// Mean returns the mean of the values recorded so far, without resetting.
+func (t *StandardResettingTimer) Mean() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return calculateResettingTimerMean(t.values)
+}
+
+// Time records the duration of the execution of the given function.
+func (t *StandardResettingTimer) Time(f func()) {
+	ts := time.Now()
+	f()
+	t.Update(time.Since(ts))
+}
+
+// Update records the duration of an event.
+func (t *StandardResettingTimer) Update(d time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.values = append(t.values, int64(d))
+}
+
+// UpdateSince records the duration of an event that started at the given
+// time.
+func (t *StandardResettingTimer) UpdateSince(ts time.Time) {
+	t.Update(time.Since(ts))
+}
+
+// ResettingTimerSnapshot is a read-only copy of a ResettingTimer's values at
+// the moment Snapshot was called. Its mean, min and max are computed lazily
+// and cached on first use; Percentiles sorts the values again on every call.
+type ResettingTimerSnapshot interface {
+	Count() int
+	Values() []int64
+	Mean() float64
+	Max() int64
+	Min() int64
+	Percentiles([]float64) []int64
+}
+
+type resettingTimerSnapshot struct {
+	values              []int64
+	mean                float64
+	min, max            int64
+	thresholdBoundaries []float64
+	calculated          bool
+}
+
+// Count returns the number of values recorded at snapshot time.
+func (t *resettingTimerSnapshot) Count() int {
+	return len(t.values)
+}
+
+// Values returns the raw values recorded at snapshot time.
+func (t *resettingTimerSnapshot) Values() []int64 {
+	return t.values
+}
+
+// Mean returns the mean of the snapshotted values.
+func (t *resettingTimerSnapshot) Mean() float64 {
+	if !t.calculated {
+		t.calc()
+	}
+	return t.mean
+}
+
+// Max returns the largest snapshotted value.
+func (t *resettingTimerSnapshot) Max() int64 {
+	if !t.calculated {
+		t.calc()
+	}
+	return t.max
+}
+
+// Min returns the smallest snapshotted value.
+func (t *resettingTimerSnapshot) Min() int64 {
+	if !t.calculated {
+		t.calc()
+	}
+	return t.min
+}
+
+// Percentiles returns the boundaries for the input percentiles.
+func (t *resettingTimerSnapshot) Percentiles(percentiles []float64) []int64 {
+	return calculateResettingTimerPercentiles(t.values, percentiles)
+}
+
+func (t *resettingTimerSnapshot) calc() {
+	t.mean = calculateResettingTimerMean(t.values)
+	if len(t.values) == 0 {
+		return
+	}
+	sorted := make([]int64, len(t.values))
+	copy(sorted, t.values)
+	sort.Sort(int64Slice(sorted))
+	t.min = sorted[0]
+	t.max = sorted[len(sorted)-1]
+	t.calculated = true
+}
+
+func calculateResettingTimerMean(values []int64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return float64(sum) / float64(len(values))
+}
+
+func calculateResettingTimerPercentiles(values []int64, percentiles []float64) []int64 {
+	scores := make([]int64, len(percentiles))
+	size := len(values)
+	if size == 0 {
+		return scores
+	}
+	sorted := make([]int64, size)
+	copy(sorted, values)
+	sort.Sort(int64Slice(sorted))
+
+	for i, p := range percentiles {
+		pos := p * float64(size+1)
+		if pos < 1.0 {
+			scores[i] = sorted[0]
+		} else if pos >= float64(size) {
+			scores[i] = sorted[size-1]
+		} else {
+			lower := sorted[int(pos)-1]
+			upper := sorted[int(pos)]
+			scores[i] = lower + int64(math.RoundToEven((pos-math.Floor(pos))*float64(upper-lower)))
+		}
+	}
+	return scores
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResettingTimerValues(t *testing.T) {
+	tm := NewResettingTimer()
+	tm.Update(50 * time.Millisecond)
+	tm.Update(100 * time.Millisecond)
+
+	values := tm.Values()
+	if len(values) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(values))
+	}
+	if values[0] != (50 * time.Millisecond).Nanoseconds() {
+		t.Errorf("values[0] = %d, want %d", values[0], (50 * time.Millisecond).Nanoseconds())
+	}
+	if values[1] != (100 * time.Millisecond).Nanoseconds() {
+		t.Errorf("values[1] = %d, want %d", values[1], (100 * time.Millisecond).Nanoseconds())
+	}
+
+	mean := tm.Mean()
+	if want := float64((50*time.Millisecond + 100*time.Millisecond).Nanoseconds()) / 2; mean != want {
+		t.Errorf("Mean() = %v, want %v", mean, want)
+	}
+}
+
+func TestResettingTimerSnapshot(t *testing.T) {
+	tm := NewResettingTimer()
+	for i := 1; i <= 100; i++ {
+		tm.Update(time.Duration(i) * time.Millisecond)
+	}
+
+	snapshot := tm.Snapshot()
+	if snapshot.Count() != 100 {
+		t.Fatalf("Count() = %d, want 100", snapshot.Count())
+	}
+	if snapshot.Min() != (1 * time.Millisecond).Nanoseconds() {
+		t.Errorf("Min() = %d, want %d", snapshot.Min(), (1 * time.Millisecond).Nanoseconds())
+	}
+	if snapshot.Max() != (100 * time.Millisecond).Nanoseconds() {
+		t.Errorf("Max() = %d, want %d", snapshot.Max(), (100 * time.Millisecond).Nanoseconds())
+	}
+
+	ps := snapshot.Percentiles([]float64{0.5})
+	if ps[0] < (49*time.Millisecond).Nanoseconds() || ps[0] > (51*time.Millisecond).Nanoseconds() {
+		t.Errorf("p50 = %d, want close to %d", ps[0], (50 * time.Millisecond).Nanoseconds())
+	}
+
+	// Snapshot should have drained the timer's own values.
+	if len(tm.Values()) != 0 {
+		t.Errorf("Values() after Snapshot = %v, want empty", tm.Values())
+	}
+}
+
+func TestResettingTimerSnapshotEmpty(t *testing.T) {
+	tm := NewResettingTimer()
+	snapshot := tm.Snapshot()
+	if snapshot.Count() != 0 {
+		t.Errorf("Count() = %d, want 0", snapshot.Count())
+	}
+	if snapshot.Mean() != 0 {
+		t.Errorf("Mean() = %v, want 0", snapshot.Mean())
+	}
+	if snapshot.Min() != 0 || snapshot.Max() != 0 {
+		t.Errorf("Min/Max = %d/%d, want 0/0", snapshot.Min(), snapshot.Max())
+	}
+}
+
+func TestResettingTimerTime(t *testing.T) {
+	tm := NewResettingTimer()
+	tm.Time(func() { time.Sleep(time.Millisecond) })
+	if len(tm.Values()) != 1 {
+		t.Fatalf("expected 1 value recorded by Time, got %d", len(tm.Values()))
+	}
+}
+
+func TestNilResettingTimer(t *testing.T) {
+	tm := NilResettingTimer{}
+	tm.Update(time.Second)
+	tm.UpdateSince(time.Now())
+	if tm.Mean() != 0 {
+		t.Errorf("Mean() = %v, want 0", tm.Mean())
+	}
+	ps := tm.Percentiles([]float64{0.5, 0.99})
+	if len(ps) != 2 || ps[0] != 0 || ps[1] != 0 {
+		t.Errorf("Percentiles() = %v, want [0 0]", ps)
+	}
+}
@@ -0,0 +1,289 @@
+package metrics
+
+import (
+	"runtime"
+	rtmetrics "runtime/metrics"
+	"sync"
+	"time"
+)
+
+var (
+	memStats       runtime.MemStats
+	runtimeMetrics struct {
+		MemStats struct {
+			Alloc         Gauge
+			BuckHashSys   Gauge
+			DebugGC       Gauge
+			EnableGC      Gauge
+			Frees         Gauge
+			HeapAlloc     Gauge
+			HeapIdle      Gauge
+			HeapInuse     Gauge
+			HeapObjects   Gauge
+			HeapReleased  Gauge
+			HeapSys       Gauge
+			LastGC        Gauge
+			Lookups       Gauge
+			Mallocs       Gauge
+			MCacheInuse   Gauge
+			MCacheSys     Gauge
+			MSpanInuse    Gauge
+			MSpanSys      Gauge
+			NextGC        Gauge
+			NumForcedGC   Gauge
+			NumGC         Gauge
+			GCCPUFraction GaugeFloat64
+			PauseNs       Histogram
+			PauseTotalNs  Gauge
+			StackInuse    Gauge
+			StackSys      Gauge
+			Sys           Gauge
+			TotalAlloc    Gauge
+		}
+		NumCgoCall   Gauge
+		NumGoroutine Gauge
+		ReadMemStats Timer
+	}
+
+	// runtimeMetricsSamples holds one runtime/metrics sample descriptor per
+	// exported metric, reused across captures so runtime/metrics.Read can
+	// fill them in place without allocating.
+	runtimeMetricsSamples []rtmetrics.Sample
+	// runtimeMetricsGauges/runtimeMetricsHistograms map a sample's index in
+	// runtimeMetricsSamples to the Registry metric it feeds.
+	runtimeMetricsGauges      = map[int]Gauge{}
+	runtimeMetricsGaugeFloats = map[int]GaugeFloat64{}
+	runtimeMetricsHistograms  = map[int]*PreBinnedSample{}
+	// runtimeMetricsPrevCounts tracks the last observed cumulative bucket
+	// counts for each histogram sample, so captures can feed PreBinnedSample
+	// the delta rather than the running total.
+	runtimeMetricsPrevCounts = map[int][]uint64{}
+	runtimeMetricsMu         sync.Mutex
+)
+
+// CaptureRuntimeMemStats is a blocking helper that periodically calls
+// CaptureRuntimeMemStatsOnce; run it in its own goroutine.
+func CaptureRuntimeMemStats(r Registry, d time.Duration) {
+	for range time.Tick(d) {
+		CaptureRuntimeMemStatsOnce(r)
+	}
+}
+
+// CaptureRuntimeMemStatsOnce samples runtime.MemStats and, on Go 1.16+, the
+// runtime/metrics package, updating every metric registered by
+// RegisterRuntimeMemStats. It must be called after RegisterRuntimeMemStats.
+func CaptureRuntimeMemStatsOnce(r Registry) {
+	t := time.Now()
+	runtime.ReadMemStats(&memStats)
+	runtimeMetrics.ReadMemStats.UpdateSince(t)
+
+	runtimeMetrics.MemStats.Alloc.Update(int64(memStats.Alloc))
+	runtimeMetrics.MemStats.BuckHashSys.Update(int64(memStats.BuckHashSys))
+	runtimeMetrics.MemStats.DebugGC.Update(b2i(memStats.DebugGC))
+	runtimeMetrics.MemStats.EnableGC.Update(b2i(memStats.EnableGC))
+	runtimeMetrics.MemStats.Frees.Update(int64(memStats.Frees))
+	runtimeMetrics.MemStats.HeapAlloc.Update(int64(memStats.HeapAlloc))
+	runtimeMetrics.MemStats.HeapIdle.Update(int64(memStats.HeapIdle))
+	runtimeMetrics.MemStats.HeapInuse.Update(int64(memStats.HeapInuse))
+	runtimeMetrics.MemStats.HeapObjects.Update(int64(memStats.HeapObjects))
+	runtimeMetrics.MemStats.HeapReleased.Update(int64(memStats.HeapReleased))
+	runtimeMetrics.MemStats.HeapSys.Update(int64(memStats.HeapSys))
+	runtimeMetrics.MemStats.LastGC.Update(int64(memStats.LastGC))
+	runtimeMetrics.MemStats.Lookups.Update(int64(memStats.Lookups))
+	runtimeMetrics.MemStats.Mallocs.Update(int64(memStats.Mallocs))
+	runtimeMetrics.MemStats.MCacheInuse.Update(int64(memStats.MCacheInuse))
+	runtimeMetrics.MemStats.MCacheSys.Update(int64(memStats.MCacheSys))
+	runtimeMetrics.MemStats.MSpanInuse.Update(int64(memStats.MSpanInuse))
+	runtimeMetrics.MemStats.MSpanSys.Update(int64(memStats.MSpanSys))
+	runtimeMetrics.MemStats.NextGC.Update(int64(memStats.NextGC))
+	runtimeMetrics.MemStats.NumForcedGC.Update(int64(memStats.NumForcedGC))
+	runtimeMetrics.MemStats.NumGC.Update(int64(memStats.NumGC))
+	runtimeMetrics.MemStats.GCCPUFraction.Update(memStats.GCCPUFraction)
+
+	i := memStats.NumGC % uint32(len(memStats.PauseNs))
+	ii := (memStats.NumGC + 255) % uint32(len(memStats.PauseNs))
+	if i > ii {
+		for ; ii < i; ii++ {
+			runtimeMetrics.MemStats.PauseNs.Update(int64(memStats.PauseNs[ii]))
+		}
+	} else {
+		for i := 0; i < 256; i++ {
+			runtimeMetrics.MemStats.PauseNs.Update(int64(memStats.PauseNs[i]))
+		}
+	}
+	runtimeMetrics.MemStats.PauseTotalNs.Update(int64(memStats.PauseTotalNs))
+	runtimeMetrics.MemStats.StackInuse.Update(int64(memStats.StackInuse))
+	runtimeMetrics.MemStats.StackSys.Update(int64(memStats.StackSys))
+	runtimeMetrics.MemStats.Sys.Update(int64(memStats.Sys))
+	runtimeMetrics.MemStats.TotalAlloc.Update(int64(memStats.TotalAlloc))
+
+	runtimeMetrics.NumCgoCall.Update(runtime.NumCgoCall())
+	runtimeMetrics.NumGoroutine.Update(int64(runtime.NumGoroutine()))
+
+	captureGoRuntimeMetricsOnce()
+}
+
+// RegisterRuntimeMemStats registers the metrics (classic runtime.MemStats
+// fields plus, on Go 1.16+, every histogram/gauge/counter exported by
+// runtime/metrics) that CaptureRuntimeMemStatsOnce fills in. Giving each a
+// fixed identity up front is what lets later captures update them in place
+// instead of re-registering on every tick.
+func RegisterRuntimeMemStats(r Registry) {
+	runtimeMetrics.MemStats.Alloc = NewGauge()
+	runtimeMetrics.MemStats.BuckHashSys = NewGauge()
+	runtimeMetrics.MemStats.DebugGC = NewGauge()
+	runtimeMetrics.MemStats.EnableGC = NewGauge()
+	runtimeMetrics.MemStats.Frees = NewGauge()
+	runtimeMetrics.MemStats.HeapAlloc = NewGauge()
+	runtimeMetrics.MemStats.HeapIdle = NewGauge()
+	runtimeMetrics.MemStats.HeapInuse = NewGauge()
+	runtimeMetrics.MemStats.HeapObjects = NewGauge()
+	runtimeMetrics.MemStats.HeapReleased = NewGauge()
+	runtimeMetrics.MemStats.HeapSys = NewGauge()
+	runtimeMetrics.MemStats.LastGC = NewGauge()
+	runtimeMetrics.MemStats.Lookups = NewGauge()
+	runtimeMetrics.MemStats.Mallocs = NewGauge()
+	runtimeMetrics.MemStats.MCacheInuse = NewGauge()
+	runtimeMetrics.MemStats.MCacheSys = NewGauge()
+	runtimeMetrics.MemStats.MSpanInuse = NewGauge()
+	runtimeMetrics.MemStats.MSpanSys = NewGauge()
+	runtimeMetrics.MemStats.NextGC = NewGauge()
+	runtimeMetrics.MemStats.NumForcedGC = NewGauge()
+	runtimeMetrics.MemStats.NumGC = NewGauge()
+	runtimeMetrics.MemStats.GCCPUFraction = NewGaugeFloat64()
+	runtimeMetrics.MemStats.PauseNs = NewHistogram(NewExpDecaySample(WithReservoirSize(1028), WithAlpha(0.015)))
+	runtimeMetrics.MemStats.PauseTotalNs = NewGauge()
+	runtimeMetrics.MemStats.StackInuse = NewGauge()
+	runtimeMetrics.MemStats.StackSys = NewGauge()
+	runtimeMetrics.MemStats.Sys = NewGauge()
+	runtimeMetrics.MemStats.TotalAlloc = NewGauge()
+
+	r.Register("runtime.MemStats.Alloc", runtimeMetrics.MemStats.Alloc)
+	r.Register("runtime.MemStats.BuckHashSys", runtimeMetrics.MemStats.BuckHashSys)
+	r.Register("runtime.MemStats.DebugGC", runtimeMetrics.MemStats.DebugGC)
+	r.Register("runtime.MemStats.EnableGC", runtimeMetrics.MemStats.EnableGC)
+	r.Register("runtime.MemStats.Frees", runtimeMetrics.MemStats.Frees)
+	r.Register("runtime.MemStats.HeapAlloc", runtimeMetrics.MemStats.HeapAlloc)
+	r.Register("runtime.MemStats.HeapIdle", runtimeMetrics.MemStats.HeapIdle)
+	r.Register("runtime.MemStats.HeapInuse", runtimeMetrics.MemStats.HeapInuse)
+	r.Register("runtime.MemStats.HeapObjects", runtimeMetrics.MemStats.HeapObjects)
+	r.Register("runtime.MemStats.HeapReleased", runtimeMetrics.MemStats.HeapReleased)
+	r.Register("runtime.MemStats.HeapSys", runtimeMetrics.MemStats.HeapSys)
+	r.Register("runtime.MemStats.LastGC", runtimeMetrics.MemStats.LastGC)
+	r.Register("runtime.MemStats.Lookups", runtimeMetrics.MemStats.Lookups)
+	r.Register("runtime.MemStats.Mallocs", runtimeMetrics.MemStats.Mallocs)
+	r.Register("runtime.MemStats.MCacheInuse", runtimeMetrics.MemStats.MCacheInuse)
+	r.Register("runtime.MemStats.MCacheSys", runtimeMetrics.MemStats.MCacheSys)
+	r.Register("runtime.MemStats.MSpanInuse", runtimeMetrics.MemStats.MSpanInuse)
+	r.Register("runtime.MemStats.MSpanSys", runtimeMetrics.MemStats.MSpanSys)
+	r.Register("runtime.MemStats.NextGC", runtimeMetrics.MemStats.NextGC)
+	r.Register("runtime.MemStats.NumForcedGC", runtimeMetrics.MemStats.NumForcedGC)
+	r.Register("runtime.MemStats.NumGC", runtimeMetrics.MemStats.NumGC)
+	r.Register("runtime.MemStats.GCCPUFraction", runtimeMetrics.MemStats.GCCPUFraction)
+	r.Register("runtime.MemStats.PauseNs", runtimeMetrics.MemStats.PauseNs)
+	r.Register("runtime.MemStats.PauseTotalNs", runtimeMetrics.MemStats.PauseTotalNs)
+	r.Register("runtime.MemStats.StackInuse", runtimeMetrics.MemStats.StackInuse)
+	r.Register("runtime.MemStats.StackSys", runtimeMetrics.MemStats.StackSys)
+	r.Register("runtime.MemStats.Sys", runtimeMetrics.MemStats.Sys)
+	r.Register("runtime.MemStats.TotalAlloc", runtimeMetrics.MemStats.TotalAlloc)
+	runtimeMetrics.NumCgoCall = NewGauge()
+	runtimeMetrics.NumGoroutine = NewGauge()
+	runtimeMetrics.ReadMemStats = NewTimer()
+
+	r.Register("runtime.NumCgoCall", runtimeMetrics.NumCgoCall)
+	r.Register("runtime.NumGoroutine", runtimeMetrics.NumGoroutine)
+	r.Register("runtime.ReadMemStats", runtimeMetrics.ReadMemStats)
+
+	registerGoRuntimeMetrics(r)
+}
+
+// registerGoRuntimeMetrics discovers every metric exported by runtime/metrics
+// and registers a Registry metric for each: a Histogram backed by a
+// PreBinnedSample for Float64Histogram-kinded descriptions, and a
+// Gauge/GaugeFloat64 for Uint64/Float64-kinded ones. It primes
+// runtimeMetricsPrevCounts with an initial read so the first
+// captureGoRuntimeMetricsOnce reports deltas rather than since-process-start
+// totals.
+func registerGoRuntimeMetrics(r Registry) {
+	runtimeMetricsMu.Lock()
+	defer runtimeMetricsMu.Unlock()
+
+	descs := rtmetrics.All()
+	runtimeMetricsSamples = make([]rtmetrics.Sample, len(descs))
+	for i, d := range descs {
+		runtimeMetricsSamples[i].Name = d.Name
+	}
+	rtmetrics.Read(runtimeMetricsSamples)
+
+	for i, d := range descs {
+		name := "runtime" + d.Name
+		v := runtimeMetricsSamples[i].Value
+		switch v.Kind() {
+		case rtmetrics.KindUint64:
+			g := NewGauge()
+			g.Update(int64(v.Uint64()))
+			runtimeMetricsGauges[i] = g
+			r.Register(name, g)
+		case rtmetrics.KindFloat64:
+			g := NewGaugeFloat64()
+			g.Update(v.Float64())
+			runtimeMetricsGaugeFloats[i] = g
+			r.Register(name, g)
+		case rtmetrics.KindFloat64Histogram:
+			h := v.Float64Histogram()
+			sample := NewPreBinnedSample(h.Buckets)
+			runtimeMetricsHistograms[i] = sample
+			runtimeMetricsPrevCounts[i] = append([]uint64(nil), h.Counts...)
+			r.Register(name, NewHistogram(sample))
+		}
+	}
+}
+
+// captureGoRuntimeMetricsOnce re-reads every runtime/metrics sample
+// registered by registerGoRuntimeMetrics and feeds the new values into the
+// corresponding Registry metric. Histogram counts are cumulative from the Go
+// runtime's point of view, so only the delta since the previous capture is
+// fed to the PreBinnedSample.
+func captureGoRuntimeMetricsOnce() {
+	runtimeMetricsMu.Lock()
+	defer runtimeMetricsMu.Unlock()
+
+	if len(runtimeMetricsSamples) == 0 {
+		return
+	}
+	rtmetrics.Read(runtimeMetricsSamples)
+
+	for i := range runtimeMetricsSamples {
+		v := runtimeMetricsSamples[i].Value
+		switch v.Kind() {
+		case rtmetrics.KindUint64:
+			if g, ok := runtimeMetricsGauges[i]; ok {
+				g.Update(int64(v.Uint64()))
+			}
+		case rtmetrics.KindFloat64:
+			if g, ok := runtimeMetricsGaugeFloats[i]; ok {
+				g.Update(v.Float64())
+			}
+		case rtmetrics.KindFloat64Histogram:
+			sample, ok := runtimeMetricsHistograms[i]
+			if !ok {
+				continue
+			}
+			h := v.Float64Histogram()
+			prev := runtimeMetricsPrevCounts[i]
+			for b, count := range h.Counts {
+				if b < len(prev) && count >= prev[b] {
+					sample.UpdateBucket(b, count-prev[b])
+				}
+			}
+			runtimeMetricsPrevCounts[i] = append(prev[:0], h.Counts...)
+		}
+	}
+}
+
+func b2i(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,571 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const rescaleThreshold = time.Hour
+
+// Sample maintains a statistically-significant selection of values from a
+// stream of int64s and the summary statistics derived from them.
+type Sample interface {
+	Clear()
+	Count() int64
+	Max() int64
+	Mean() float64
+	Min() int64
+	Percentile(float64) float64
+	Percentiles([]float64) []float64
+	Size() int
+	Snapshot() Sample
+	StdDev() float64
+	Sum() int64
+	Update(int64)
+	Values() []int64
+	Variance() float64
+}
+
+// SampleOption configures an ExpDecaySample at construction time.
+type SampleOption func(*ExpDecaySample)
+
+// WithReservoirSize sets the number of values an ExpDecaySample keeps.
+func WithReservoirSize(n int) SampleOption {
+	return func(s *ExpDecaySample) { s.reservoirSize = n }
+}
+
+// WithAlpha sets the forward-decay factor of an ExpDecaySample: higher
+// values bias the reservoir more heavily towards recent values.
+func WithAlpha(alpha float64) SampleOption {
+	return func(s *ExpDecaySample) { s.alpha = alpha }
+}
+
+// WithRescaleThreshold sets how often an ExpDecaySample rescales its
+// priorities to avoid floating-point overflow for long-lived samples.
+func WithRescaleThreshold(d time.Duration) SampleOption {
+	return func(s *ExpDecaySample) { s.rescaleThreshold = d }
+}
+
+// NewExpDecaySample constructs a new ExpDecaySample, which uses a
+// forward-decaying priority reservoir of the kind described in Cormode et
+// al.'s "Forward Decay: A Practical Time Decay Model for Streaming Systems".
+// It biases its reservoir towards recently-seen values, so percentiles
+// reflect recent behavior even when values arrive far faster than the
+// reservoir can hold.
+func NewExpDecaySample(opts ...SampleOption) Sample {
+	if UseNilMetrics {
+		return NilSample{}
+	}
+	s := &ExpDecaySample{
+		alpha:            0.015,
+		reservoirSize:    1028,
+		rescaleThreshold: rescaleThreshold,
+		clock:            clock.New(),
+		values:           newExpDecaySampleHeap(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	now := s.clock.Now()
+	s.setTime(now)
+	return s
+}
+
+// ExpDecaySample is the standard implementation of a forward-decaying
+// priority Sample.
+type ExpDecaySample struct {
+	mutex            sync.Mutex
+	alpha            float64
+	count            int64
+	reservoirSize    int
+	rescaleThreshold time.Duration
+	t0, t1           time.Time
+	values           *expDecaySampleHeap
+	clock            clock.Clock
+	rand             *rand.Rand
+}
+
+// Clear resets the sample to its initial empty state.
+func (s *ExpDecaySample) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.count = 0
+	s.values.Clear()
+	s.setTime(s.clock.Now())
+}
+
+// Count returns the number of values ever recorded, which may exceed Size
+// once the reservoir is full.
+func (s *ExpDecaySample) Count() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+// Max returns the largest value in the reservoir.
+func (s *ExpDecaySample) Max() int64 {
+	return SampleMax(s.Values())
+}
+
+// Mean returns the mean of the values in the reservoir.
+func (s *ExpDecaySample) Mean() float64 {
+	return SampleMean(s.Values())
+}
+
+// Min returns the smallest value in the reservoir.
+func (s *ExpDecaySample) Min() int64 {
+	return SampleMin(s.Values())
+}
+
+// Percentile returns a single percentile boundary.
+func (s *ExpDecaySample) Percentile(p float64) float64 {
+	return SamplePercentile(s.Values(), p)
+}
+
+// Percentiles returns the boundaries for the given percentiles.
+func (s *ExpDecaySample) Percentiles(ps []float64) []float64 {
+	return SamplePercentiles(s.Values(), ps)
+}
+
+// Size returns the number of values currently held in the reservoir.
+func (s *ExpDecaySample) Size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expireIfStale()
+	return s.values.Size()
+}
+
+// Snapshot returns a read-only copy of the sample's current reservoir.
+func (s *ExpDecaySample) Snapshot() Sample {
+	values := s.Values()
+	return &SampleSnapshot{count: s.Count(), values: values}
+}
+
+// StdDev returns the standard deviation of the values in the reservoir.
+func (s *ExpDecaySample) StdDev() float64 {
+	return SampleStdDev(s.Values())
+}
+
+// Sum returns the sum of the values in the reservoir.
+func (s *ExpDecaySample) Sum() int64 {
+	return SampleSum(s.Values())
+}
+
+// Update records a value, using the sample's clock to timestamp it.
+func (s *ExpDecaySample) Update(v int64) {
+	s.update(s.clock.Now(), v)
+}
+
+// Values returns every value currently held in the reservoir, in no
+// particular order.
+func (s *ExpDecaySample) Values() []int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expireIfStale()
+	elements := s.values.Values()
+	values := make([]int64, len(elements))
+	for i, e := range elements {
+		values[i] = e.v
+	}
+	return values
+}
+
+// Variance returns the variance of the values in the reservoir.
+func (s *ExpDecaySample) Variance() float64 {
+	return SampleVariance(s.Values())
+}
+
+// setTime anchors the sample's decay window at t, used both at construction
+// and whenever a rescale is triggered.
+func (s *ExpDecaySample) setTime(t time.Time) {
+	s.t0 = t
+	s.t1 = s.t0.Add(s.rescaleThreshold)
+}
+
+func (s *ExpDecaySample) random() float64 {
+	if s.rand != nil {
+		return s.rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// expireIfStale drops the reservoir once the clock has passed t1
+// (rescaleThreshold after t0) with no Update since then to trigger a
+// rescale; values recorded between t0 and t1 are dropped too. There is no fresh value to
+// recalibrate decayed priorities against, so the reservoir is treated as
+// stale and dropped outright rather than carried forward; it is called from
+// the read side (Values, Size) so a sample that simply stops receiving
+// updates eventually reports empty instead of serving ancient data forever.
+func (s *ExpDecaySample) expireIfStale() {
+	now := s.clock.Now()
+	if now.After(s.t1) {
+		s.values.Clear()
+		s.setTime(now)
+	}
+}
+
+// update is the timestamped implementation behind Update; tests call it
+// directly with explicit timestamps to get reproducible statistics.
+func (s *ExpDecaySample) update(t time.Time, v int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.count++
+	if s.values.Size() == s.reservoirSize {
+		s.values.Pop()
+	}
+	s.values.Push(expDecaySample{
+		k: math.Exp(t.Sub(s.t0).Seconds()*s.alpha) / s.random(),
+		v: v,
+	})
+	if t.After(s.t1) {
+		oldValues := s.values.Values()
+		t0 := s.t0
+		s.values.Clear()
+		s.setTime(t)
+		for _, e := range oldValues {
+			e.k = e.k * math.Exp(-s.alpha*s.t0.Sub(t0).Seconds())
+			s.values.Push(e)
+		}
+	}
+}
+
+// NewUniformSample constructs a Sample using Vitter's algorithm R, which
+// gives every value recorded an equal chance of being retained once the
+// reservoir is full, regardless of when it arrived.
+func NewUniformSample(reservoirSize int) Sample {
+	if UseNilMetrics {
+		return NilSample{}
+	}
+	return &UniformSample{
+		reservoirSize: reservoirSize,
+		values:        make([]int64, 0, reservoirSize),
+	}
+}
+
+// UniformSample is the standard implementation of a uniform Sample.
+type UniformSample struct {
+	mutex         sync.Mutex
+	count         int64
+	reservoirSize int
+	values        []int64
+}
+
+// Clear resets the sample to its initial empty state.
+func (s *UniformSample) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.count = 0
+	s.values = make([]int64, 0, s.reservoirSize)
+}
+
+// Count returns the number of values ever recorded, which may exceed Size
+// once the reservoir is full.
+func (s *UniformSample) Count() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+// Max returns the largest value in the reservoir.
+func (s *UniformSample) Max() int64 { return SampleMax(s.Values()) }
+
+// Mean returns the mean of the values in the reservoir.
+func (s *UniformSample) Mean() float64 { return SampleMean(s.Values()) }
+
+// Min returns the smallest value in the reservoir.
+func (s *UniformSample) Min() int64 { return SampleMin(s.Values()) }
+
+// Percentile returns a single percentile boundary.
+func (s *UniformSample) Percentile(p float64) float64 {
+	return SamplePercentile(s.Values(), p)
+}
+
+// Percentiles returns the boundaries for the given percentiles.
+func (s *UniformSample) Percentiles(ps []float64) []float64 {
+	return SamplePercentiles(s.Values(), ps)
+}
+
+// Size returns the number of values currently held in the reservoir.
+func (s *UniformSample) Size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.values)
+}
+
+// Snapshot returns a read-only copy of the sample's current reservoir.
+func (s *UniformSample) Snapshot() Sample {
+	return &SampleSnapshot{count: s.Count(), values: s.Values()}
+}
+
+// StdDev returns the standard deviation of the values in the reservoir.
+func (s *UniformSample) StdDev() float64 { return SampleStdDev(s.Values()) }
+
+// Sum returns the sum of the values in the reservoir.
+func (s *UniformSample) Sum() int64 { return SampleSum(s.Values()) }
+
+// Update records a value, replacing a uniformly random existing value once
+// the reservoir is full so every value seen has an equal chance of survival.
+func (s *UniformSample) Update(v int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.count++
+	if len(s.values) < s.reservoirSize {
+		s.values = append(s.values, v)
+		return
+	}
+	if i := rand.Int63n(s.count); i < int64(s.reservoirSize) {
+		s.values[i] = v
+	}
+}
+
+// Values returns every value currently held in the reservoir, in no
+// particular order.
+func (s *UniformSample) Values() []int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	values := make([]int64, len(s.values))
+	copy(values, s.values)
+	return values
+}
+
+// Variance returns the variance of the values in the reservoir.
+func (s *UniformSample) Variance() float64 { return SampleVariance(s.Values()) }
+
+// SampleSnapshot is a read-only copy of a Sample's values at the moment
+// Snapshot was called.
+type SampleSnapshot struct {
+	count  int64
+	values []int64
+}
+
+// NewSampleSnapshot constructs a SampleSnapshot directly from count and
+// values, for reporters and tests that already have both in hand.
+func NewSampleSnapshot(count int64, values []int64) *SampleSnapshot {
+	return &SampleSnapshot{count: count, values: values}
+}
+
+func (s *SampleSnapshot) Clear()        { panic("metrics: Clear called on a SampleSnapshot") }
+func (s *SampleSnapshot) Count() int64  { return s.count }
+func (s *SampleSnapshot) Max() int64    { return SampleMax(s.values) }
+func (s *SampleSnapshot) Mean() float64 { return SampleMean(s.values) }
+func (s *SampleSnapshot) Min() int64    { return SampleMin(s.values) }
+func (s *SampleSnapshot) Percentile(p float64) float64 {
+	return SamplePercentile(s.values, p)
+}
+func (s *SampleSnapshot) Percentiles(ps []float64) []float64 {
+	return SamplePercentiles(s.values, ps)
+}
+func (s *SampleSnapshot) Size() int { return len(s.values) }
+func (s *SampleSnapshot) Snapshot() Sample {
+	return s
+}
+func (s *SampleSnapshot) StdDev() float64 { return SampleStdDev(s.values) }
+func (s *SampleSnapshot) Sum() int64      { return SampleSum(s.values) }
+func (s *SampleSnapshot) Update(int64) {
+	panic("metrics: Update called on a SampleSnapshot")
+}
+func (s *SampleSnapshot) Values() []int64   { return s.values }
+func (s *SampleSnapshot) Variance() float64 { return SampleVariance(s.values) }
+
+// NilSample is a no-op Sample.
+type NilSample struct{}
+
+func (NilSample) Clear()                     {}
+func (NilSample) Count() int64               { return 0 }
+func (NilSample) Max() int64                 { return 0 }
+func (NilSample) Mean() float64              { return 0.0 }
+func (NilSample) Min() int64                 { return 0 }
+func (NilSample) Percentile(float64) float64 { return 0.0 }
+func (NilSample) Percentiles(ps []float64) []float64 {
+	return make([]float64, len(ps))
+}
+func (NilSample) Size() int         { return 0 }
+func (NilSample) Snapshot() Sample  { return NilSample{} }
+func (NilSample) StdDev() float64   { return 0.0 }
+func (NilSample) Sum() int64        { return 0 }
+func (NilSample) Update(int64)      {}
+func (NilSample) Values() []int64   { return []int64{} }
+func (NilSample) Variance() float64 { return 0.0 }
+
+// SampleMax returns the maximum value of the slice of int64.
+func SampleMax(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	max := values[0]
+	for _, v := range values[1:] {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// SampleMin returns the minimum value of the slice of int64.
+func SampleMin(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// SampleSum returns the sum of the slice of int64.
+func SampleSum(values []int64) int64 {
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+// SampleMean returns the mean value of the slice of int64.
+func SampleMean(values []int64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+	return float64(SampleSum(values)) / float64(len(values))
+}
+
+// SampleVariance returns the variance of the slice of int64.
+func SampleVariance(values []int64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+	m := SampleMean(values)
+	var sum float64
+	for _, v := range values {
+		d := float64(v) - m
+		sum += d * d
+	}
+	return sum / float64(len(values))
+}
+
+// SampleStdDev returns the standard deviation of the slice of int64.
+func SampleStdDev(values []int64) float64 {
+	return math.Sqrt(SampleVariance(values))
+}
+
+// SamplePercentile returns a single percentile boundary for p in [0, 1].
+func SamplePercentile(values []int64, p float64) float64 {
+	return SamplePercentiles(values, []float64{p})[0]
+}
+
+// SamplePercentiles returns the boundaries for the given percentiles (each
+// in [0, 1]) using linear interpolation between closest ranks.
+func SamplePercentiles(values []int64, ps []float64) []float64 {
+	scores := make([]float64, len(ps))
+	size := len(values)
+	if size == 0 {
+		return scores
+	}
+	sorted := make([]int64, size)
+	copy(sorted, values)
+	sort.Sort(int64Slice(sorted))
+
+	for i, p := range ps {
+		pos := p * float64(size+1)
+		if pos < 1.0 {
+			scores[i] = float64(sorted[0])
+		} else if pos >= float64(size) {
+			scores[i] = float64(sorted[size-1])
+		} else {
+			lower := float64(sorted[int(pos)-1])
+			upper := float64(sorted[int(pos)])
+			scores[i] = lower + (pos-math.Floor(pos))*(upper-lower)
+		}
+	}
+	return scores
+}
+
+// expDecaySample is one entry in an expDecaySampleHeap: a recorded value v
+// with the forward-decay priority k it was given at insertion time.
+type expDecaySample struct {
+	k float64
+	v int64
+}
+
+// expDecaySampleHeap is a min-heap of expDecaySample, ordered by k, used to
+// cheaply find (and replace) the lowest-priority element in the reservoir.
+type expDecaySampleHeap struct {
+	s []expDecaySample
+}
+
+func newExpDecaySampleHeap() *expDecaySampleHeap {
+	return &expDecaySampleHeap{}
+}
+
+func (h *expDecaySampleHeap) Clear() {
+	h.s = h.s[:0]
+}
+
+func (h *expDecaySampleHeap) Size() int {
+	return len(h.s)
+}
+
+func (h *expDecaySampleHeap) Values() []expDecaySample {
+	return h.s
+}
+
+func (h *expDecaySampleHeap) Push(e expDecaySample) {
+	h.s = append(h.s, e)
+	h.up(len(h.s) - 1)
+}
+
+// Pop removes and returns the lowest-priority element.
+func (h *expDecaySampleHeap) Pop() expDecaySample {
+	n := len(h.s) - 1
+	h.s[0], h.s[n] = h.s[n], h.s[0]
+	h.down(0, n)
+	popped := h.s[n]
+	h.s = h.s[:n]
+	return popped
+}
+
+func (h *expDecaySampleHeap) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if h.s[parent].k <= h.s[i].k {
+			break
+		}
+		h.s[parent], h.s[i] = h.s[i], h.s[parent]
+		i = parent
+	}
+}
+
+// down restores the heap property at i, considering only the first n
+// elements (so Pop can sift down after swapping the root with the last live
+// element, without the just-evicted slot interfering).
+func (h *expDecaySampleHeap) down(i, n int) {
+	for {
+		left, right := 2*i+1, 2*i+2
+		smallest := i
+		if left < n && h.s[left].k < h.s[smallest].k {
+			smallest = left
+		}
+		if right < n && h.s[right].k < h.s[smallest].k {
+			smallest = right
+		}
+		if smallest == i {
+			return
+		}
+		h.s[i], h.s[smallest] = h.s[smallest], h.s[i]
+		i = smallest
+	}
+}
+
+type int64Slice []int64
+
+func (p int64Slice) Len() int           { return len(p) }
+func (p int64Slice) Less(i, j int) bool { return p[i] < p[j] }
+func (p int64Slice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
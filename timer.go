@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Timer captures the duration and rate of events, combining a Histogram of
+// durations with a Meter of event counts.
+type Timer interface {
+	Count() int64
+	Max() int64
+	Mean() float64
+	Min() int64
+	Percentile(float64) float64
+	Percentiles([]float64) []float64
+	Rate1() float64
+	Rate5() float64
+	Rate15() float64
+	RateMean() float64
+	Snapshot() Timer
+	StdDev() float64
+	Stop()
+	Sum() int64
+	Time(func())
+	Update(time.Duration)
+	UpdateSince(time.Time)
+	Variance() float64
+}
+
+// GetOrRegisterTimer returns an existing Timer or constructs and registers a
+// new StandardTimer.
+func GetOrRegisterTimer(name string, r Registry) Timer {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewTimer).(Timer)
+}
+
+// NewTimer constructs a new StandardTimer, recording durations in
+// nanoseconds into an ExpDecaySample-backed Histogram.
+func NewTimer() Timer {
+	if UseNilMetrics {
+		return NilTimer{}
+	}
+	return &StandardTimer{
+		histogram: NewHistogram(NewExpDecaySample(WithReservoirSize(1028), WithAlpha(0.015))),
+		meter:     NewMeter(),
+	}
+}
+
+// NewRegisteredTimer constructs and registers a new StandardTimer.
+func NewRegisteredTimer(name string, r Registry) Timer {
+	t := NewTimer()
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, t)
+	return t
+}
+
+// NilTimer is a no-op Timer.
+type NilTimer struct{}
+
+func (NilTimer) Count() int64               { return 0 }
+func (NilTimer) Max() int64                 { return 0 }
+func (NilTimer) Mean() float64              { return 0.0 }
+func (NilTimer) Min() int64                 { return 0 }
+func (NilTimer) Percentile(float64) float64 { return 0.0 }
+func (NilTimer) Percentiles(ps []float64) []float64 {
+	return make([]float64, len(ps))
+}
+func (NilTimer) Rate1() float64        { return 0.0 }
+func (NilTimer) Rate5() float64        { return 0.0 }
+func (NilTimer) Rate15() float64       { return 0.0 }
+func (NilTimer) RateMean() float64     { return 0.0 }
+func (NilTimer) Snapshot() Timer       { return NilTimer{} }
+func (NilTimer) StdDev() float64       { return 0.0 }
+func (NilTimer) Stop()                 {}
+func (NilTimer) Sum() int64            { return 0 }
+func (NilTimer) Time(f func())         { f() }
+func (NilTimer) Update(time.Duration)  {}
+func (NilTimer) UpdateSince(time.Time) {}
+func (NilTimer) Variance() float64     { return 0.0 }
+
+// StandardTimer is the standard implementation of a Timer: a Histogram of
+// durations (in nanoseconds) alongside a Meter tracking event rate.
+type StandardTimer struct {
+	mutex     sync.Mutex
+	histogram Histogram
+	meter     Meter
+}
+
+func (t *StandardTimer) Count() int64  { return t.histogram.Count() }
+func (t *StandardTimer) Max() int64    { return t.histogram.Max() }
+func (t *StandardTimer) Mean() float64 { return t.histogram.Mean() }
+func (t *StandardTimer) Min() int64    { return t.histogram.Min() }
+func (t *StandardTimer) Percentile(p float64) float64 {
+	return t.histogram.Percentile(p)
+}
+func (t *StandardTimer) Percentiles(ps []float64) []float64 {
+	return t.histogram.Percentiles(ps)
+}
+func (t *StandardTimer) Rate1() float64    { return t.meter.Rate1() }
+func (t *StandardTimer) Rate5() float64    { return t.meter.Rate5() }
+func (t *StandardTimer) Rate15() float64   { return t.meter.Rate15() }
+func (t *StandardTimer) RateMean() float64 { return t.meter.RateMean() }
+
+// Snapshot returns a timer whose histogram and meter are both read-only
+// copies taken at the moment Snapshot was called.
+func (t *StandardTimer) Snapshot() Timer {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return &StandardTimer{
+		histogram: t.histogram.Snapshot(),
+		meter:     t.meter.Snapshot(),
+	}
+}
+
+func (t *StandardTimer) StdDev() float64 { return t.histogram.StdDev() }
+func (t *StandardTimer) Stop()           { t.meter.Stop() }
+func (t *StandardTimer) Sum() int64      { return t.histogram.Sum() }
+
+// Time records the duration of f's execution.
+func (t *StandardTimer) Time(f func()) {
+	ts := time.Now()
+	f()
+	t.Update(time.Since(ts))
+}
+
+// Update records the duration of an event.
+func (t *StandardTimer) Update(d time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.histogram.Update(int64(d))
+	t.meter.Mark(1)
+}
+
+// UpdateSince records the duration of an event that started at ts.
+func (t *StandardTimer) UpdateSince(ts time.Time) {
+	t.Update(time.Since(ts))
+}
+
+func (t *StandardTimer) Variance() float64 { return t.histogram.Variance() }